@@ -0,0 +1,198 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/describe"
+	"github.com/marianozunino/code/v2/internal/gitstatus"
+	"github.com/marianozunino/code/v2/internal/stats"
+)
+
+// gitStatusTimeout bounds the git status call of a single project
+const gitStatusTimeout = 2 * time.Second
+
+// annotationCaches hold the last known values of slow annotations
+type annotationCaches struct {
+	git          *gitstatus.Cache
+	descriptions *describe.Cache
+}
+
+// openAnnotationCaches loads the caches from their configured files
+func openAnnotationCaches() *annotationCaches {
+	return &annotationCaches{
+		git:          gitstatus.NewCache(cfg.GitStatusCache),
+		descriptions: describe.NewCache(cfg.DescriptionCache),
+	}
+}
+
+// Save writes the caches that changed and counts their lookups
+func (c *annotationCaches) Save() {
+	c.git.Save()
+	c.descriptions.Save()
+
+	gitHits, gitMisses := c.git.Lookups()
+	descriptionHits, descriptionMisses := c.descriptions.Lookups()
+	recordStats(stats.Counters{
+		CacheHits:   gitHits + descriptionHits,
+		CacheMisses: gitMisses + descriptionMisses,
+	})
+}
+
+// gitAnnotators returns annotators setting Branch and Dirty ("*" with
+// uncommitted changes) from the git status of a project, and from the
+// cached status of the previous run
+func gitAnnotators(cache *gitstatus.Cache) (annotate, cached core.Annotator) {
+	annotate = func(project string, data map[string]string) {
+		ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+		defer cancel()
+
+		dir := projectPath(project)
+		status, err := gitstatus.Read(ctx, dir)
+		if err != nil {
+			setGitStatus(data, gitstatus.Status{})
+			return
+		}
+		cache.Put(dir, status)
+		setGitStatus(data, status)
+	}
+
+	cached = func(project string, data map[string]string) {
+		if status, ok := cache.Get(projectPath(project)); ok {
+			setGitStatus(data, status)
+		}
+	}
+
+	return annotate, cached
+}
+
+// setGitStatus exposes a git status to templates
+func setGitStatus(data map[string]string, status gitstatus.Status) {
+	data["Branch"] = status.Branch
+	data["Dirty"] = ""
+	if status.Dirty {
+		data["Dirty"] = "*"
+	}
+}
+
+// editorContextAnnotator exposes the project context to editor templates:
+// Branch, RemoteURL, Language, AbsPath and BaseDir
+func editorContextAnnotator(dir string, data map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	defer cancel()
+
+	data["Branch"] = ""
+	if status, err := gitstatus.Read(ctx, dir); err == nil {
+		data["Branch"] = status.Branch
+	}
+	data["RemoteURL"], _ = gitstatus.RemoteURL(ctx, dir)
+	data["Language"] = core.DetectLanguage(dir)
+	data["AbsPath"] = dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		data["AbsPath"] = abs
+	}
+	data["BaseDir"] = cfg.BaseDir
+}
+
+// descriptionAnnotators return annotators setting Description from the
+// project manifests or README, and from the cached description
+func descriptionAnnotators(cache *describe.Cache) (annotate, cached core.Annotator) {
+	annotate = func(project string, data map[string]string) {
+		dir := projectPath(project)
+		data["Description"] = describe.Read(dir)
+		cache.Put(dir, data["Description"])
+	}
+
+	cached = func(project string, data map[string]string) {
+		if description, ok := cache.Get(projectPath(project)); ok {
+			data["Description"] = description
+		}
+	}
+
+	return annotate, cached
+}
+
+// sizeAnnotator sets Size to the disk usage of a project, e.g. "12M",
+// leaving out the directories matching skipDirs as the scan does
+func sizeAnnotator(skipDirs []string) func(project string, data map[string]string) {
+	return func(project string, data map[string]string) {
+		data["Size"] = ""
+
+		root := projectPath(project)
+		var total int64
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if d.IsDir() && path != root && skippedDir(skipDirs, d.Name()) {
+				return fs.SkipDir
+			}
+			if d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					total += info.Size()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		data["Size"] = humanizeSize(total)
+	}
+}
+
+// skippedDir reports whether a directory name matches one of the
+// skip_dirs globs
+func skippedDir(skipDirs []string, name string) bool {
+	for _, pattern := range skipDirs {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// humanizeSize renders a byte count with a binary unit suffix
+func humanizeSize(size int64) string {
+	const units = "KMGTPE"
+	if size < 1024 {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	value := float64(size)
+	unit := -1
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", value, units[unit])
+	}
+	return fmt.Sprintf("%.0f%c", value, units[unit])
+}
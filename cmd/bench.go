@@ -0,0 +1,184 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchRuns      int
+	benchSynthetic int
+	benchJSON      bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure how long project scans take",
+	Long: `Scan the base dir repeatedly with the configured scan_depth and
+skip_dirs, and report the p50 and p95 durations and the allocations per
+scan, to check scanner changes on real machines.
+
+Warm scans run after a first untimed scan, with the directories in the
+kernel's caches. Cold scans drop those caches before each scan, which
+needs root on Linux; they are skipped otherwise. --synthetic scans a
+generated tree of that many projects in a temporary directory instead.`,
+	Example: `  code bench
+  code bench -n 50 --synthetic 5000
+  sudo code bench --base-dir ~/Dev --json`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().IntVarP(&benchRuns, "runs", "n", 10, "scans per series")
+	benchCmd.Flags().IntVar(&benchSynthetic, "synthetic", 0, "scan a generated tree of this many projects instead of the base dir")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "print the results as JSON")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchReport is the result of bench as printed by bench --json
+type benchReport struct {
+	Dir      string       `json:"dir"`
+	Projects int          `json:"projects"`
+	Runs     int          `json:"runs"`
+	Warm     *benchSeries `json:"warm"`
+	Cold     *benchSeries `json:"cold"` // nil when caches cannot be dropped
+}
+
+// benchSeries summarizes the scans of a series, durations in milliseconds
+type benchSeries struct {
+	MinMs       float64 `json:"min_ms"`
+	P50Ms       float64 `json:"p50_ms"`
+	P95Ms       float64 `json:"p95_ms"`
+	MaxMs       float64 `json:"max_ms"`
+	MeanMs      float64 `json:"mean_ms"`
+	AllocsPerOp uint64  `json:"allocs_per_op"`
+	BytesPerOp  uint64  `json:"bytes_per_op"`
+}
+
+// runBench scans the base dir or a synthetic tree and reports the timings
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchRuns < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+
+	dir := cfg.BaseDir
+	if benchSynthetic > 0 {
+		tmp, err := os.MkdirTemp("", "code-bench-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		start := time.Now()
+		if err := bench.Generate(tmp, benchSynthetic); err != nil {
+			return err
+		}
+		slog.Info("generated projects", "count", benchSynthetic, "took", time.Since(start).Round(time.Millisecond))
+		dir = tmp
+	}
+
+	finder := projectFinder()
+	scan := func() { finder.FindProjects(dir) }
+
+	// The first scan warms the caches up
+	report := benchReport{Dir: dir, Runs: benchRuns, Projects: len(finder.FindProjects(dir))}
+
+	warm := make([]bench.Sample, benchRuns)
+	for i := range warm {
+		warm[i] = bench.Measure(scan)
+	}
+	report.Warm = newBenchSeries(bench.Summarize(warm))
+
+	cold := make([]bench.Sample, 0, benchRuns)
+	for range benchRuns {
+		if err := bench.DropCaches(); err != nil {
+			if !errors.Is(err, bench.ErrCannotDropCaches) {
+				return err
+			}
+			slog.Warn("skipping cold scans", "err", err)
+			break
+		}
+		cold = append(cold, bench.Measure(scan))
+	}
+	if len(cold) == benchRuns {
+		report.Cold = newBenchSeries(bench.Summarize(cold))
+	}
+
+	if benchJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printBenchReport(report)
+	return nil
+}
+
+// newBenchSeries converts a summary for the report
+func newBenchSeries(s bench.Summary) *benchSeries {
+	return &benchSeries{
+		MinMs:       milliseconds(s.Min),
+		P50Ms:       milliseconds(s.P50),
+		P95Ms:       milliseconds(s.P95),
+		MaxMs:       milliseconds(s.Max),
+		MeanMs:      milliseconds(s.Mean),
+		AllocsPerOp: s.AllocsPerOp,
+		BytesPerOp:  s.BytesPerOp,
+	}
+}
+
+// milliseconds returns a duration in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// printBenchReport prints the report as a table
+func printBenchReport(report benchReport) {
+	fmt.Printf("dir:       %s\n", report.Dir)
+	fmt.Printf("projects:  %d\n", report.Projects)
+	fmt.Printf("runs:      %d per series\n\n", report.Runs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tp50\tp95\tmin\tmax\tmean\tallocs/op\tbytes/op\t")
+	for _, series := range []struct {
+		name string
+		*benchSeries
+	}{{"warm", report.Warm}, {"cold", report.Cold}} {
+		if series.benchSeries == nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\t-\t\n", series.name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.2fms\t%.2fms\t%.2fms\t%.2fms\t%.2fms\t%d\t%d\t\n", series.name,
+			series.P50Ms, series.P95Ms, series.MinMs, series.MaxMs, series.MeanMs, series.AllocsPerOp, series.BytesPerOp)
+	}
+	w.Flush()
+}
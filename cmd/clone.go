@@ -0,0 +1,199 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultCloneLayout places clones like host/owner/repo under the base dir
+const defaultCloneLayout = "{{.Host}}/{{.Owner}}/{{.Repo}}"
+
+var cloneNoOpen bool
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <url> [path] [-- git clone args...]",
+	Short: "Clone a repository into the base dir and open it",
+	Long: `Clone a git repository into the base dir and open it straight away.
+
+The directory is derived from the URL with the clone_layout template, by
+default {{.Host}}/{{.Owner}}/{{.Repo}}, or given as a path relative to the
+base dir. A repository that is already cloned there is just opened.
+
+Arguments after -- are passed to git clone.`,
+	Example: `  code clone https://github.com/marianozunino/code
+  code clone git@github.com:marianozunino/code.git tools/code
+  code clone https://github.com/golang/go -- --depth 1`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		names := len(args)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			names = dash
+		}
+		if names < 1 || names > 2 {
+			return fmt.Errorf("accepts a url and an optional path before --, received %d", names)
+		}
+		return nil
+	},
+	RunE: cloneProject,
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().BoolVar(&cloneNoOpen, "no-open", false, "only clone the repository and add it to the recent projects")
+	cloneCmd.Flags().StringVar(&editorName, "editor", "", "open the project with a named editor profile from the selector file, or an editor preset")
+	cloneCmd.Flags().BoolVar(&here, "here", false, "run the editor in the current terminal instead of opening a window")
+	cloneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the clone destination and editor command instead of running them")
+	cloneCmd.RegisterFlagCompletionFunc("editor", completeEditors)
+}
+
+// repoURL holds the parts of a repository URL clone layouts use
+type repoURL struct {
+	Host  string
+	Owner string
+	Repo  string
+}
+
+// parseRepoURL splits https://, ssh:// and scp-like git@host:owner/repo
+// URLs. Owner holds every path element but the last, so GitLab subgroups
+// are kept.
+func parseRepoURL(raw string) (repoURL, error) {
+	var host, path string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return repoURL{}, fmt.Errorf("invalid repository URL %q: %w", raw, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if before, after, ok := strings.Cut(raw, ":"); ok && !strings.Contains(before, "/") {
+		host, path = before[strings.LastIndex(before, "@")+1:], after
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return repoURL{}, fmt.Errorf("cannot derive a project path from %q, pass one after the URL", raw)
+	}
+	repo := repoURL{Host: host, Repo: path}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		repo.Owner, repo.Repo = path[:i], path[i+1:]
+	}
+	return repo, nil
+}
+
+// cloneDestination returns the path relative to the base dir a repository
+// is cloned into
+func cloneDestination(raw string, args []string) (string, error) {
+	var dest string
+	if len(args) > 0 {
+		dest = args[0]
+	} else {
+		repo, err := parseRepoURL(raw)
+		if err != nil {
+			return "", err
+		}
+		layout := cfg.CloneLayout
+		if layout == "" {
+			layout = defaultCloneLayout
+		}
+		tmpl, err := template.New("clone_layout").Option("missingkey=error").Parse(layout)
+		if err != nil {
+			return "", fmt.Errorf("invalid clone_layout: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, repo); err != nil {
+			return "", fmt.Errorf("invalid clone_layout: %w", err)
+		}
+		dest = buf.String()
+	}
+
+	dest = filepath.Clean(dest)
+	if filepath.IsAbs(dest) || dest == "." || dest == ".." || strings.HasPrefix(dest, "../") {
+		return "", fmt.Errorf("clone path %q is not inside the base dir", dest)
+	}
+	return dest, nil
+}
+
+// cloneProject clones a repository into the base dir and opens it
+func cloneProject(cmd *cobra.Command, args []string) error {
+	names, gitArgs := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		names, gitArgs = args[:dash], args[dash:]
+	}
+
+	project, err := cloneDestination(names[0], names[1:])
+	if err != nil {
+		return err
+	}
+	dest := projectPath(project)
+
+	if dryRun {
+		if !isDirectory(dest) {
+			fmt.Printf("git clone %s %s\n", strings.Join(append(gitArgs, names[0]), " "), dest)
+			return nil
+		}
+	} else if err := gitClone(names[0], dest, gitArgs); err != nil {
+		return err
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	if cloneNoOpen {
+		if dryRun {
+			return nil
+		}
+		return mruList.Update(project)
+	}
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+	return openListed(mruList, remotes, project, nil)
+}
+
+// gitClone clones url into dest, leaving a repository already there alone
+func gitClone(url, dest string, gitArgs []string) error {
+	if isDirectory(filepath.Join(dest, ".git")) {
+		slog.Info("already cloned", "path", dest)
+		return nil
+	}
+
+	cmdArgs := append([]string{"clone"}, gitArgs...)
+	git := exec.Command("git", append(cmdArgs, url, dest)...)
+	git.Stdin = os.Stdin
+	git.Stdout = os.Stdout
+	git.Stderr = os.Stderr
+	if err := git.Run(); err != nil {
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+	return nil
+}
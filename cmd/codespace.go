@@ -0,0 +1,190 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/marianozunino/code/v2/internal/tui"
+)
+
+// How codespaces are opened, set by codespace_mode
+const (
+	codespaceSSH  = "ssh"  // A terminal running gh codespace ssh
+	codespaceCode = "code" // VS Code connected to the codespace
+)
+
+// inCodespace opens projects in a codespace of their GitHub repository
+var inCodespace bool
+
+// codespaceAppID is the Wayland app_id or X11 class of VS Code windows
+const codespaceAppID = "code"
+
+// openCodespace launches or focuses the most recently used codespace of
+// the GitHub repository of a local project
+func openCodespace(selector *core.Selector, mruList *mru.MRUList, project string) error {
+	fullPath := filepath.Join(cfg.BaseDir, project)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
+	mode, err := codespaceMode()
+	if err != nil {
+		return err
+	}
+	ws, repo, err := projectCodespace(fullPath)
+	if err != nil {
+		return err
+	}
+
+	provider := &remote.Codespaces{}
+	windowTitle := fmt.Sprintf("%s ~ %s", ws.Provider, ws.Name)
+	if here {
+		if mode != codespaceSSH {
+			return fmt.Errorf("--here opens codespaces over ssh only, codespace_mode is %s", mode)
+		}
+		if !tui.IsTerminal(os.Stdin) || !tui.IsTerminal(os.Stdout) {
+			return fmt.Errorf("--here needs an interactive terminal")
+		}
+		// Deferred saves never run once the process is replaced
+		if err := mruList.Update(project); err != nil {
+			return err
+		}
+		if err := mruList.Flush(); err != nil {
+			return err
+		}
+		if err := openHistory().Append(fullPath); err != nil {
+			return err
+		}
+		return execHere(fullPath, provider.ConnectCommand(ws))
+	}
+
+	var start func() error
+	var find windowFinder
+	if mode == codespaceCode {
+		// VS Code titles the window after the workspace folder, which is
+		// /workspaces/<repo> in codespaces
+		start = func() error { return selector.Launch(provider.CodeCommand(ws)) }
+		find = byApp(codespaceAppID, repo.Repo)
+	} else {
+		var launched func() int
+		start, launched = trackLaunch(selector, func() error { return selector.StartRemote(windowTitle, provider.ConnectCommand(ws)) })
+		find = firstFound(byProcess(launched), byTitle(windowTitle))
+		if newWindow {
+			// Another terminal attached to the same codespace
+			find = byProcess(launched)
+		}
+	}
+
+	_, err = launchOrFocusWindow(start, find)
+	if launchFailed(err) {
+		return fmt.Errorf("failed to launch/focus window: %w", err)
+	}
+	if err := mruList.Update(project); err != nil {
+		return err
+	}
+	if err := recordLaunch(fullPath, windowTitle); err != nil {
+		return err
+	}
+	return err
+}
+
+// codespaceMode returns the configured way of opening codespaces
+func codespaceMode() (string, error) {
+	switch cfg.CodespaceMode {
+	case "", codespaceSSH:
+		return codespaceSSH, nil
+	case codespaceCode:
+		return codespaceCode, nil
+	default:
+		return "", fmt.Errorf("invalid codespace_mode %q (want %s or %s)", cfg.CodespaceMode, codespaceSSH, codespaceCode)
+	}
+}
+
+// projectCodespace returns the most recently used codespace of the GitHub
+// repository the origin remote of a project points at
+func projectCodespace(fullPath string) (remote.Workspace, repoURL, error) {
+	repo, err := githubRepo(fullPath)
+	if err != nil {
+		return remote.Workspace{}, repo, err
+	}
+	name := repo.Owner + "/" + repo.Repo
+	codespaces, err := (&remote.Codespaces{}).ForRepo(name)
+	if err != nil {
+		return remote.Workspace{}, repo, err
+	}
+	if len(codespaces) == 0 {
+		return remote.Workspace{}, repo, fmt.Errorf("%s has no codespace, create one with: gh codespace create -R %s", name, name)
+	}
+	return codespaces[0], repo, nil
+}
+
+// githubRepo returns the repository of the origin remote of a project,
+// which has to be on GitHub
+func githubRepo(fullPath string) (repoURL, error) {
+	output, err := exec.Command("git", "-C", fullPath, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return repoURL{}, fmt.Errorf("%s has no origin remote", filepath.Base(fullPath))
+	}
+	repo, err := parseRepoURL(strings.TrimSpace(string(output)))
+	if err != nil {
+		return repoURL{}, err
+	}
+	if !strings.EqualFold(repo.Host, "github.com") {
+		return repoURL{}, fmt.Errorf("%s is not on GitHub (origin is on %s)", filepath.Base(fullPath), repo.Host)
+	}
+	return repo, nil
+}
+
+// printCodespaceDryRun shows the codespace a project would open in and
+// the command connecting to it
+func printCodespaceDryRun(project string) error {
+	fullPath := filepath.Join(cfg.BaseDir, project)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
+	mode, err := codespaceMode()
+	if err != nil {
+		return err
+	}
+	ws, repo, err := projectCodespace(fullPath)
+	if err != nil {
+		return err
+	}
+
+	provider := &remote.Codespaces{}
+	fmt.Printf("project:  %s (codespace %s)\n", project, ws.Name)
+	if mode == codespaceCode {
+		fmt.Printf("connect:  %s\n", core.ShellJoin(provider.CodeCommand(ws)))
+		fmt.Printf("window:   %s window naming %s\n", codespaceAppID, repo.Repo)
+		return nil
+	}
+	fmt.Printf("connect:  %s\n", core.ShellJoin(provider.ConnectCommand(ws)))
+	fmt.Printf("window:   %s ~ %s\n", ws.Provider, ws.Name)
+	return nil
+}
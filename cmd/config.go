@@ -0,0 +1,360 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configForce       bool
+	configInteractive bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration files",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write commented default configuration files",
+	Long: `Init writes ~/.code.yaml (or the file given with --config) and a selector
+file under $XDG_CONFIG_HOME/code (or the file given with --selector-file),
+with every setting commented.
+
+The terminal and selector are the first presets found on PATH; with
+--interactive the projects directory, terminal and selector are asked for.
+Existing files are left alone unless --force is given.`,
+	Example: `  code config init
+  code config init --interactive`,
+	Args: cobra.NoArgs,
+	RunE: initConfigFiles,
+}
+
+func init() {
+	configInitCmd.Flags().BoolVar(&configForce, "force", false, "overwrite existing configuration files")
+	configInitCmd.Flags().BoolVarP(&configInteractive, "interactive", "i", false, "ask for the projects directory, terminal and selector")
+
+	configCmd.AddCommand(configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configSettings are the choices the generated files are written with
+type configSettings struct {
+	BaseDir      string
+	SelectorFile string
+	Selector     string
+	Terminal     string
+	Editor       string
+}
+
+// mainConfigTemplate is the generated ~/.code.yaml
+const mainConfigTemplate = `# code launcher settings
+
+# Files these settings are merged over, e.g. shared between machines
+# include: [~/dotfiles/code/settings.yaml]
+
+# Directory searched for projects
+base_dir: [[ quote .BaseDir ]]
+
+# Selector, editor and display settings
+selector_file: [[ quote .SelectorFile ]]
+
+# Order of the project list: mru, frecency, alpha or recently-modified
+sort: mru
+
+# How deep to look for projects (0 for any depth) and directories to skip
+scan_depth: 0
+skip_dirs: [node_modules, vendor]
+
+# Where code clone puts repositories under the base dir
+# clone_layout: "{{.Host}}/{{.Owner}}/{{.Repo}}"
+
+# Where code worktree puts worktrees under the base dir
+# worktree_layout: "{{.Project}}-wt/{{.Branch}}"
+
+# Where code rm --archive moves projects, on the filesystem of the base dir
+# archive_dir: ~/.code_archive
+
+# Editor profile of the selector file to open projects with
+# editor_profile: vscode
+
+# Overrides for other base dirs, applied while one of them is the base dir
+# dirs:
+#   - path: /home/me/Work
+#     scan_depth: 2
+#     editor_profile: idea
+
+# Window manager: auto, gnome, kde, sway, hyprland, river, i3, wlroots, x11
+# or none to only launch
+window_backend: auto
+
+# Move focused windows to the current workspace instead of switching to theirs
+bring_windows: false
+
+# How long to look for an open window, and to wait for a launched one
+window_find_timeout: 1s
+window_wait_timeout: 2s
+
+# Remember how long editor windows stay open, see code sessions
+track_sessions: false
+
+# Remote workspaces, e.g. [devpod], and projects on other hosts over SSH
+# remote_providers: []
+# ssh_projects: ["host:~/src/api"]
+
+# GitLab and Gitea/Forgejo repositories to list and clone when selected,
+# with tokens from token_env (GITLAB_TOKEN, GITEA_TOKEN, FORGEJO_TOKEN)
+# forges:
+#   - type: gitlab
+#     url: https://gitlab.example.com
+
+# Variables for templates and launched programs, e.g. tokens of remote
+# providers, as NAME=value lines
+# env_file: ~/.config/code/env
+
+# Output of launched editors and terminals
+# launch_log: ~/.local/state/code/launch.log
+`
+
+// selectorConfigTemplate is the generated selector file
+const selectorConfigTemplate = `# code selector file
+
+# Files this one is merged over, e.g. shared editor profiles
+# include: [~/dotfiles/code/editors.yaml]
+
+# Project selector, a preset or a command with args:
+# [[ join .Selectors ", " ]]
+selector: [[ .Selector ]]
+
+# Terminal for plain terminals and editors running inside one:
+# [[ join .Terminals ", " ]]
+terminal: [[ .Terminal ]]
+
+# Editor, a preset or a command with an args template:
+# [[ join .Editors ", " ]]
+editor: [[ .Editor ]]
+
+# Named editor profiles, picked with --editor or an editor:<name> action
+# editors:
+#   vscode: vscode
+
+# Editor profiles picked by project language or files at their root
+# editor_rules:
+#   - language: Java
+#     editor: idea
+
+# How tmux sessions are named: basename, parent or hash
+session_naming: basename
+
+# Load the project environment: auto, direnv or nix
+# project_env: auto
+
+# How projects are listed in the selector and read back from it
+format:
+  project_title: "📘 {{.Path}}"
+  extract_path: "{{.Title | trimPrefix \"📘 \"}}"
+`
+
+// initConfigFiles writes the default configuration files
+func initConfigFiles(cmd *cobra.Command, args []string) error {
+	settings, err := defaultSettings()
+	if err != nil {
+		return err
+	}
+	if configInteractive {
+		if err := askSettings(bufio.NewReader(os.Stdin), &settings); err != nil {
+			return err
+		}
+	}
+	if settings.Terminal == "kitty" {
+		settings.Editor = "kitty-tmux"
+	}
+
+	mainFile := cfgFile
+	if mainFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		mainFile = filepath.Join(home, ".code.yaml")
+	}
+
+	files := []struct {
+		path, template string
+	}{
+		{mainFile, mainConfigTemplate},
+		{settings.SelectorFile, selectorConfigTemplate},
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil && !configForce {
+			return fmt.Errorf("%s exists, pass --force to overwrite it", f.path)
+		}
+	}
+	for _, f := range files {
+		data, err := renderConfig(f.template, settings)
+		if err != nil {
+			return err
+		}
+		if err := writeConfigFile(f.path, data); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", f.path)
+	}
+	return nil
+}
+
+// defaultSettings picks the first terminal and selector installed
+func defaultSettings() (configSettings, error) {
+	selectorFile := selectorFile
+	if selectorFile == "" {
+		configHome, err := os.UserConfigDir()
+		if err != nil {
+			return configSettings{}, err
+		}
+		selectorFile = filepath.Join(configHome, "code", "selector.yaml")
+	}
+
+	return configSettings{
+		BaseDir:      cfg.BaseDir,
+		SelectorFile: selectorFile,
+		Selector:     firstInstalled([]string{"fuzzel", "rofi", "wofi", "fzf"}, core.BuiltinSelector),
+		Terminal:     firstInstalled([]string{"kitty", "foot", "alacritty", "wezterm", "ghostty"}, "kitty"),
+		Editor:       "tmux-nvim",
+	}, nil
+}
+
+// firstInstalled returns the first command found on PATH, or fallback
+func firstInstalled(commands []string, fallback string) string {
+	for _, command := range commands {
+		if _, err := exec.LookPath(command); err == nil {
+			return command
+		}
+	}
+	return fallback
+}
+
+// askSettings asks for the projects directory, terminal and selector,
+// keeping the defaults on empty answers
+func askSettings(reader *bufio.Reader, settings *configSettings) error {
+	questions := []struct {
+		question string
+		value    *string
+		choices  []string
+	}{
+		{"Projects directory", &settings.BaseDir, nil},
+		{"Terminal", &settings.Terminal, core.TerminalPresetNames()},
+		{"Selector", &settings.Selector, core.SelectorPresetNames()},
+	}
+
+	for _, q := range questions {
+		for {
+			if q.choices != nil {
+				fmt.Printf("%s (%s) [%s]: ", q.question, strings.Join(q.choices, ", "), *q.value)
+			} else {
+				fmt.Printf("%s [%s]: ", q.question, *q.value)
+			}
+			answer, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read answer: %w", err)
+			}
+			answer = strings.TrimSpace(answer)
+			if answer == "" {
+				break
+			}
+			if q.choices != nil && !slices.Contains(q.choices, answer) {
+				fmt.Printf("unknown choice %q\n", answer)
+				continue
+			}
+			*q.value = answer
+			break
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(settings.BaseDir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		settings.BaseDir = filepath.Join(home, rest)
+	}
+	return nil
+}
+
+// renderConfig renders a configuration file template. Its own delimiters
+// leave the templates of the file alone.
+func renderConfig(src string, settings configSettings) ([]byte, error) {
+	tmpl, err := template.New("config").Delims("[[", "]]").Funcs(template.FuncMap{
+		"quote": strconv.Quote,
+		"join":  strings.Join,
+	}).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{
+		"BaseDir":      settings.BaseDir,
+		"SelectorFile": settings.SelectorFile,
+		"Selector":     settings.Selector,
+		"Terminal":     settings.Terminal,
+		"Editor":       settings.Editor,
+		"Selectors":    core.SelectorPresetNames(),
+		"Terminals":    core.TerminalPresetNames(),
+		"Editors":      core.EditorPresetNames(),
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeConfigFile atomically writes a configuration file, creating its
+// directory
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/daemon"
+	"github.com/marianozunino/code/v2/internal/stats"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// daemonQueryTimeout bounds queries to the daemon, after which the
+	// client does the work itself
+	daemonQueryTimeout = time.Second
+	// daemonOpenTimeout bounds open requests, which wait for the window
+	daemonOpenTimeout = 30 * time.Second
+	// rescanDelay is how long the tree must be quiet before a rescan
+	rescanDelay = 200 * time.Millisecond
+)
+
+// daemonIndex is the project index of the running daemon, nil in clients
+var daemonIndex *projectIndex
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the project index warm and serve other invocations",
+	Long: `Run in the background, keeping the project index of the base dir up to date
+by watching the scanned directories, and answer other invocations over a
+Unix socket (daemon_socket).
+
+While it runs, commands take the project list from its index instead of
+scanning, and "code open" hands the launch to it, so keybindings open
+projects without any scan. Invocations with other settings than the
+daemon's, e.g. another base dir, profile or --set, do the work themselves.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon runs and what it indexed",
+	Args:  cobra.NoArgs,
+	RunE:  daemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := daemon.Call(cfg.DaemonSocket, daemon.Request{Op: daemon.OpStop}, daemonQueryTimeout)
+		return err
+	},
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStatusCmd, daemonStopCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// runDaemon indexes the base dir and serves requests until stopped
+func runDaemon(cmd *cobra.Command, args []string) error {
+	if cfg.DaemonSocket == "" {
+		return fmt.Errorf("no daemon_socket configured")
+	}
+	listener, err := daemon.Listen(cfg.DaemonSocket)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	index, err := newProjectIndex()
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	defer index.Close()
+	daemonIndex = index
+
+	go index.watch(ctx)
+	go func() {
+		config := newConfigWatcher()
+		ticker := time.NewTicker(configCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if config.reloadIfChanged() {
+				index.scan()
+			}
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	slog.Info("listening", "socket", cfg.DaemonSocket)
+	return daemon.Serve(listener, func(req daemon.Request) daemon.Response {
+		return handleDaemonRequest(req, stop)
+	})
+}
+
+// handleDaemonRequest answers a request, one at a time since opening
+// projects goes through the command globals
+func handleDaemonRequest(req daemon.Request, stop func()) daemon.Response {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	switch req.Op {
+	case daemon.OpStatus:
+		projects, scanned := daemonIndex.snapshot()
+		return daemon.Response{PID: os.Getpid(), BaseDir: cfg.BaseDir, Projects: projects, Scanned: scanned}
+	case daemon.OpStop:
+		stop()
+		return daemon.Response{}
+	}
+
+	if reason := settingsMismatch(req); reason != "" {
+		return daemon.Response{Declined: true, Error: reason}
+	}
+
+	switch req.Op {
+	case daemon.OpProjects:
+		projects, scanned := daemonIndex.snapshot()
+		return daemon.Response{Projects: projects, Scanned: scanned}
+	case daemon.OpOpen:
+		project, err := openForClient(req)
+		if err != nil {
+			return daemon.Response{Error: err.Error()}
+		}
+		return daemon.Response{Project: project}
+	default:
+		return daemon.Response{Error: fmt.Sprintf("unknown request %q", req.Op)}
+	}
+}
+
+// settingsMismatch explains how the settings of a request differ from the
+// daemon's, empty when they match
+func settingsMismatch(req daemon.Request) string {
+	dir := daemonIndex.settings()
+	switch {
+	case req.Profile != profile:
+		return fmt.Sprintf("daemon runs with profile %q", profile)
+	case filepath.Clean(req.BaseDir) != filepath.Clean(dir.Path):
+		return fmt.Sprintf("daemon indexes %s", dir.Path)
+	case req.ScanDepth != dir.ScanDepth || !slices.Equal(req.SkipDirs, dir.SkipDirs):
+		return "daemon scans with other scan_depth or skip_dirs"
+	}
+	return ""
+}
+
+// openForClient opens a project named in a request the way code open does
+func openForClient(req daemon.Request) (string, error) {
+	editorName, newWindow = req.Editor, req.NewWindow
+	defer func() { editorName, newWindow = "", false }()
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return "", err
+	}
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return "", err
+	}
+	project, err := core.ResolveProject(req.Project, projects)
+	if err != nil {
+		return "", err
+	}
+	return project, openListed(mruList, remotes, project, req.ExtraArgs)
+}
+
+// daemonStatus prints what the running daemon indexed
+func daemonStatus(cmd *cobra.Command, args []string) error {
+	resp, err := daemon.Call(cfg.DaemonSocket, daemon.Request{Op: daemon.OpStatus}, daemonQueryTimeout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pid:       %d\n", resp.PID)
+	fmt.Printf("socket:    %s\n", cfg.DaemonSocket)
+	fmt.Printf("base dir:  %s\n", resp.BaseDir)
+	fmt.Printf("projects:  %d, scanned %s\n", len(resp.Projects), resp.Scanned.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// daemonRequest returns a request carrying the settings of this invocation
+func daemonRequest(op string) daemon.Request {
+	dir := dirConfig()
+	return daemon.Request{
+		Op:        op,
+		Profile:   profile,
+		BaseDir:   dir.Path,
+		ScanDepth: dir.ScanDepth,
+		SkipDirs:  dir.SkipDirs,
+	}
+}
+
+// daemonProjects returns the projects of the base dir from the index of a
+// running daemon
+func daemonProjects() ([]string, error) {
+	if cfg.DaemonSocket == "" {
+		return nil, daemon.ErrNotRunning
+	}
+	resp, err := daemon.Call(cfg.DaemonSocket, daemonRequest(daemon.OpProjects), daemonQueryTimeout)
+	return resp.Projects, err
+}
+
+// daemonOpen hands opening a project over to a running daemon. It returns
+// daemon.ErrNotRunning or daemon.ErrDeclined when the caller should open
+// the project itself.
+func daemonOpen(cmd *cobra.Command, name string, extraArgs []string) error {
+	if cfg.DaemonSocket == "" || overridesGiven(cmd) {
+		return daemon.ErrNotRunning
+	}
+	req := daemonRequest(daemon.OpOpen)
+	req.Project = name
+	req.Editor = editorName
+	req.NewWindow = newWindow
+	req.ExtraArgs = extraArgs
+	_, err := daemon.Call(cfg.DaemonSocket, req, daemonOpenTimeout)
+	return err
+}
+
+// overridesGiven reports whether global flags override settings, which the
+// daemon would not see
+func overridesGiven(cmd *cobra.Command) bool {
+	given := false
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if !loggingFlags[flag.Name] && cmd.Flags().Changed(flag.Name) {
+			given = true
+		}
+	})
+	return given
+}
+
+// projectIndex holds the projects of the base dir, scanned again when
+// directories are created or removed in the scanned tree
+type projectIndex struct {
+	mu       sync.Mutex
+	dir      DirConfig // Settings the index was scanned with
+	projects []string
+	scanned  time.Time
+	watcher  *fsnotify.Watcher
+	watched  map[string]bool
+}
+
+// newProjectIndex scans the base dir and watches it
+func newProjectIndex() (*projectIndex, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the base dir: %w", err)
+	}
+	index := &projectIndex{watcher: watcher, watched: map[string]bool{}}
+	index.scan()
+	return index, nil
+}
+
+// Close stops watching
+func (idx *projectIndex) Close() error {
+	return idx.watcher.Close()
+}
+
+// scan finds the projects of the base dir and watches the directories it
+// looked in for new ones
+func (idx *projectIndex) scan() {
+	configMu.Lock()
+	dir := dirConfig()
+	finder := projectFinder()
+	configMu.Unlock()
+
+	looked := map[string]bool{}
+	finder.OnDir = func(path string) { looked[path] = true }
+	start := time.Now()
+	projects := finder.FindProjects(dir.Path)
+	recordStats(stats.Counters{Scans: 1, ScanTime: time.Since(start)})
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for path := range idx.watched {
+		if !looked[path] {
+			idx.watcher.Remove(path)
+			delete(idx.watched, path)
+		}
+	}
+	for path := range looked {
+		if idx.watched[path] {
+			continue
+		}
+		if err := idx.watcher.Add(path); err != nil {
+			slog.Warn("cannot watch directory", "path", path, "err", err)
+			continue
+		}
+		idx.watched[path] = true
+	}
+
+	idx.dir = dir
+	idx.projects = projects
+	idx.scanned = time.Now()
+}
+
+// watch scans again once directories stop changing, until ctx is done
+func (idx *projectIndex) watch(ctx context.Context) {
+	var rescan <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-idx.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) && !isDirectory(event.Name) && filepath.Base(event.Name) != ".git" {
+				continue // Only directories and worktree .git files make projects
+			}
+			if event.Has(fsnotify.Create | fsnotify.Remove | fsnotify.Rename) {
+				rescan = time.After(rescanDelay)
+			}
+		case err, ok := <-idx.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("watching the base dir failed", "err", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				rescan = time.After(rescanDelay)
+			}
+		case <-rescan:
+			rescan = nil
+			idx.scan()
+		}
+	}
+}
+
+// snapshot returns the indexed projects and when they were scanned
+func (idx *projectIndex) snapshot() ([]string, time.Time) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return slices.Clone(idx.projects), idx.scanned
+}
+
+// settings returns the settings the index was scanned with
+func (idx *projectIndex) settings() DirConfig {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.dir
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/marianozunino/code/v2/internal/core"
+)
+
+// DirConfig overrides settings while its directory is the base dir
+type DirConfig struct {
+	Path          string   `mapstructure:"path"`
+	EditorProfile string   `mapstructure:"editor_profile"`
+	ScanDepth     int      `mapstructure:"scan_depth"`
+	SkipDirs      []string `mapstructure:"skip_dirs"`
+}
+
+// dirConfig returns the settings for the current base dir: the top level
+// ones with those of its dirs entry merged over them
+func dirConfig() DirConfig {
+	merged := DirConfig{
+		Path:          cfg.BaseDir,
+		EditorProfile: cfg.EditorProfile,
+		ScanDepth:     cfg.ScanDepth,
+		SkipDirs:      cfg.SkipDirs,
+	}
+
+	for _, dir := range cfg.Dirs {
+		if filepath.Clean(dir.Path) != filepath.Clean(cfg.BaseDir) {
+			continue
+		}
+		if dir.EditorProfile != "" {
+			merged.EditorProfile = dir.EditorProfile
+		}
+		if dir.ScanDepth != 0 {
+			merged.ScanDepth = dir.ScanDepth
+		}
+		if dir.SkipDirs != nil {
+			merged.SkipDirs = dir.SkipDirs
+		}
+		break
+	}
+	return merged
+}
+
+// projectFinder returns the finder for the projects of the base dir
+func projectFinder() *core.ProjectFinder {
+	dir := dirConfig()
+	return &core.ProjectFinder{MaxDepth: dir.ScanDepth, SkipDirs: dir.SkipDirs}
+}
+
+// editorProfileName returns the editor profile picked with --editor, or
+// else the one configured for the base dir
+func editorProfileName() string {
+	if editorName != "" {
+		return editorName
+	}
+	return dirConfig().EditorProfile
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/remote"
+)
+
+// printDryRun prints the selector commands when given and the editor
+// command and window title for a project, without running anything
+func printDryRun(selector *core.Selector, remotes *remote.Registry, project string, withSelector bool) error {
+	if withSelector {
+		for _, command := range selector.SelectorCommands() {
+			fmt.Printf("selector: %s\n", command)
+		}
+	}
+
+	fmt.Printf("backend:  %s\n", windowBackend().Name())
+
+	if ws, provider, ok := remotes.Lookup(project); ok {
+		cloner, hosted := provider.(remote.Cloner)
+		if !hosted {
+			fmt.Printf("project:  %s (remote workspace)\n", project)
+			fmt.Printf("connect:  %s\n", core.ShellJoin(provider.ConnectCommand(ws)))
+			fmt.Printf("window:   %s ~ %s\n", ws.Provider, ws.Name)
+			return nil
+		}
+
+		// Hosted repositories are shown as the project they are cloned into
+		cloneURL := cloner.CloneURL(ws)
+		local, err := cloneDestination(cloneURL, nil)
+		if err != nil {
+			return err
+		}
+		if !isDirectory(filepath.Join(projectPath(local), ".git")) {
+			fmt.Printf("project:  %s (hosted repository)\n", project)
+			fmt.Printf("clone:    git clone %s %s\n", cloneURL, projectPath(local))
+			return nil
+		}
+		project = local
+	}
+	if inCodespace {
+		return printCodespaceDryRun(project)
+	}
+
+	fullPath := filepath.Join(cfg.BaseDir, project)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
+	selector = selector.ForProject(fullPath)
+	windowTitle := editorWindowTitle(selector, fullPath)
+	if newWindow && !here {
+		windowTitle = newWindowInstance(selector, fullPath, windowTitle)
+	}
+
+	fmt.Printf("project:  %s\n", project)
+	if up := selector.ContainerUpCommand(fullPath); up != nil {
+		fmt.Printf("container: %s\n", core.ShellJoin(up))
+	}
+	layout, err := selector.LayoutCommands(fullPath, windowTitle)
+	if err != nil {
+		return err
+	}
+	for _, command := range layout {
+		fmt.Printf("layout:   %s\n", core.ShellJoin(command))
+	}
+	if here {
+		argv, err := selector.HereCommand(fullPath, windowTitle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("editor:   %s\n", core.ShellJoin(argv))
+		fmt.Printf("window:   (current terminal)\n")
+		return nil
+	}
+	fmt.Printf("editor:   %s\n", core.ShellJoin(selector.EditorCommand(fullPath, windowTitle)))
+	fmt.Printf("window:   %s\n", windowTitle)
+	return nil
+}
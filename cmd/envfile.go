@@ -0,0 +1,69 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/marianozunino/code/v2/internal/dotenv"
+)
+
+// envFileVars are the variables set from env_file, which a reload may
+// change again
+var envFileVars = map[string]bool{}
+
+// loadEnvFile sets the variables of the env file that the environment does
+// not set itself, so templates and everything launched see them. A missing
+// file is only warned about, for config files shared with machines
+// without it.
+func loadEnvFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	path, err := expandHome(path)
+	if err != nil {
+		return err
+	}
+
+	vars, err := dotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Warn("env_file does not exist", "path", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load env_file: %w", err)
+	}
+
+	for key, value := range vars {
+		if _, set := os.LookupEnv(key); set && !envFileVars[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		envFileVars[key] = true
+	}
+	return nil
+}
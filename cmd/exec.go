@@ -0,0 +1,175 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/spf13/cobra"
+)
+
+// Variables set for commands run by code exec
+const (
+	projectEnvName = "CODE_PROJECT"     // Project name, relative to the base dir
+	projectEnvDir  = "CODE_PROJECT_DIR" // Absolute project directory
+)
+
+var (
+	execAll  bool
+	execJobs int
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <project> -- <command> [args...]",
+	Short: "Run a command in a project directory",
+	Long: `Run a command in the directory of a project, resolved by name like code
+open, with the project environment of project_env loaded and
+CODE_PROJECT and CODE_PROJECT_DIR set. The command replaces code, so its
+exit status is the command's.
+
+With --all the command runs in every local project, --jobs at a time, and
+the output of each is printed under the project name once it finishes.`,
+	Example: `  code exec api -- make test
+  code exec --all -- git status --short
+  code exec --all -j 2 -- sh -c 'echo "$CODE_PROJECT: $(git branch --show-current)"'`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE:              execInProject,
+}
+
+func init() {
+	execCmd.Flags().BoolVar(&execAll, "all", false, "run the command in every project")
+	execCmd.Flags().IntVarP(&execJobs, "jobs", "j", runtime.NumCPU(), "projects the command runs in at once with --all")
+	rootCmd.AddCommand(execCmd)
+}
+
+// execInProject runs the command in one project, or in all of them
+func execInProject(cmd *cobra.Command, args []string) error {
+	argv := args
+	if !execAll {
+		argv = args[1:]
+	}
+	switch dash := cmd.ArgsLenAtDash(); {
+	case execAll && dash > 0:
+		return fmt.Errorf("--all takes no project, the command follows --")
+	case !execAll && dash == 0:
+		return fmt.Errorf("no project given, it goes before --")
+	case !execAll && dash > 1:
+		return fmt.Errorf("exec takes a single project, the command follows --")
+	}
+	if len(argv) == 0 {
+		return fmt.Errorf("no command given, put it after --")
+	}
+	// Errors past the arguments are not about usage
+	cmd.SilenceUsage = true
+
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	selector := core.NewSelector(appConfig)
+
+	if execAll {
+		return execAllProjects(selector, argv)
+	}
+
+	project, err := resolveLocalProject(args[0])
+	if err != nil {
+		return err
+	}
+	dir := projectPath(project)
+	if !isDirectory(dir) {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+	os.Setenv(projectEnvName, project)
+	os.Setenv(projectEnvDir, dir)
+	return execHere(dir, selector.ProjectCommand(dir, argv))
+}
+
+// execAllProjects runs the command in every project, --jobs at a time,
+// printing the output of each project in list order
+func execAllProjects(selector *core.Selector, argv []string) error {
+	if execJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	projects := scanProjects()
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	results := make([]chan result, len(projects))
+	sem := make(chan struct{}, execJobs)
+	for i, project := range projects {
+		results[i] = make(chan result, 1)
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			output, err := runInProject(selector, project, argv)
+			results[i] <- result{output, err}
+		}()
+	}
+
+	var failed []string
+	for i, project := range projects {
+		r := <-results[i]
+		fmt.Printf("==> %s\n", project)
+		os.Stdout.Write(r.output)
+		if r.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", project, r.err)
+			failed = append(failed, project)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed in %d of %d projects: %s", len(failed), len(projects), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runInProject runs argv in a project and returns its combined output
+func runInProject(selector *core.Selector, project string, argv []string) ([]byte, error) {
+	dir := projectPath(project)
+	argv = selector.ProjectCommand(dir, argv)
+
+	var output bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), projectEnvName+"="+project, projectEnvDir+"="+dir)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.Bytes(), fmt.Errorf("exit status %d", exitErr.ExitCode())
+	}
+	return output.Bytes(), err
+}
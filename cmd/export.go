@@ -0,0 +1,108 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/position"
+	"github.com/spf13/cobra"
+)
+
+// stateVersion is the format version of exported state
+const stateVersion = 1
+
+// stateBundle is the launcher state moved between machines. Paths are
+// absolute; those under BaseDir are moved under the base dir of the
+// importing machine.
+type stateBundle struct {
+	Version   int                          `json:"version"`
+	Exported  time.Time                    `json:"exported"`
+	BaseDir   string                       `json:"base_dir"`
+	Projects  []mru.Item                   `json:"projects"`
+	History   []history.Entry              `json:"history"`
+	Positions map[string]position.Position `json:"positions"`
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write the recent and pinned projects, history and positions to a file",
+	Long: `Write the launcher state to a JSON file, or stdout when no file or "-" is
+given: the recent and pinned projects, the launch history and the last
+editor positions. code import reads it back, e.g. on another machine.
+
+Pins are the only favorites; there are no tags, aliases or registered
+projects to export, projects are whatever the base dir holds. Left out on
+purpose are the git status and description caches, rebuilt from the
+projects themselves, the session records and usage stats, which describe
+this machine, and the config files, which belong with your dotfiles.`,
+	Example: `  code export ~/dotfiles/code-state.json
+  code export | ssh laptop code import`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: exportState,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+// exportState writes the state bundle
+func exportState(cmd *cobra.Command, args []string) error {
+	entries, err := openHistory().Entries()
+	if err != nil {
+		return err
+	}
+	positions, err := openPositions().All()
+	if err != nil {
+		return err
+	}
+
+	bundle := stateBundle{
+		Version:   stateVersion,
+		Exported:  time.Now(),
+		BaseDir:   cfg.BaseDir,
+		Projects:  openMRU().Export(),
+		History:   entries,
+		Positions: positions,
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	data = append(data, '\n')
+
+	if len(args) == 0 || args[0] == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(args[0], data, 0o600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	fmt.Printf("Exported %d projects, %d history entries and %d positions to %s\n",
+		len(bundle.Projects), len(bundle.History), len(bundle.Positions), args[0])
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/remote"
+)
+
+// ForgeConfig is a GitLab or Gitea/Forgejo instance whose repositories are
+// listed next to local projects and cloned when selected
+type ForgeConfig struct {
+	Type     string `mapstructure:"type"`      // gitlab, gitea or forgejo
+	Name     string `mapstructure:"name"`      // Label prefix, the type by default
+	URL      string `mapstructure:"url"`       // The public instance of the type by default
+	TokenEnv string `mapstructure:"token_env"` // e.g. GITLAB_TOKEN for gitlab
+	Protocol string `mapstructure:"protocol"`  // ssh or https
+}
+
+// addForges enables the configured forges, with tokens read from the
+// environment, which env_file can set
+func addForges(remotes *remote.Registry) error {
+	for _, fc := range cfg.Forges {
+		tokenEnv := fc.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = strings.ToUpper(fc.Type) + "_TOKEN"
+		}
+		switch fc.Protocol {
+		case "", "ssh", "https":
+		default:
+			return fmt.Errorf("invalid protocol %q for forge %s (want ssh or https)", fc.Protocol, fc.Type)
+		}
+
+		forge, err := remote.NewForge(fc.Type, fc.Name, fc.URL, os.Getenv(tokenEnv), fc.Protocol == "https")
+		if err != nil {
+			return err
+		}
+		remotes.Add(forge)
+	}
+	return nil
+}
+
+// cloneOnSelect clones a hosted repository into the base dir, where
+// clone_layout puts it, and returns it as a local project. A repository
+// already there is left alone.
+func cloneOnSelect(cloner remote.Cloner, ws remote.Workspace) (string, error) {
+	cloneURL := cloner.CloneURL(ws)
+	project, err := cloneDestination(cloneURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := gitClone(cloneURL, projectPath(project), nil); err != nil {
+		return "", err
+	}
+	return project, nil
+}
+
+// clonedWorkspace reports whether a hosted repository is cloned into the
+// base dir already, where it is listed as a local project
+func clonedWorkspace(remotes *remote.Registry, ws remote.Workspace) bool {
+	_, provider, _ := remotes.Lookup(ws.Label())
+	cloner, ok := provider.(remote.Cloner)
+	if !ok {
+		return false
+	}
+	project, err := cloneDestination(cloner.CloneURL(ws), nil)
+	return err == nil && isDirectory(filepath.Join(projectPath(project), ".git"))
+}
@@ -0,0 +1,224 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/position"
+	"github.com/marianozunino/code/v2/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepIgnoreCase bool
+	grepJobs       int
+	grepBuiltIn    bool
+	grepJSON       bool
+	grepSelect     bool
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Search the files of every project",
+	Long: `Search the files of every local project for a regular expression and
+print the matches grouped by project. Projects are searched in parallel
+with ripgrep when it is installed, which skips ignored and binary files,
+or else with a built-in search skipping hidden directories and skip_dirs.
+
+With --select the matches are shown in the selector, and the chosen one's
+project is opened, with {{.LastFile}} and {{.LastLine}} set to the match.`,
+	Example: `  code grep 'func main'
+  code grep -i todo --select
+  code grep --json 'api_key' | jq -r .[].project`,
+	Args: cobra.ExactArgs(1),
+	RunE: grepProjects,
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "match case insensitively")
+	grepCmd.Flags().IntVarP(&grepJobs, "jobs", "j", runtime.NumCPU(), "projects searched at once")
+	grepCmd.Flags().BoolVar(&grepBuiltIn, "built-in", false, "search without ripgrep even when it is installed")
+	grepCmd.Flags().BoolVar(&grepJSON, "json", false, "print the matches as JSON")
+	grepCmd.Flags().BoolVar(&grepSelect, "select", false, "pick a match in the selector and open its project")
+	grepCmd.Flags().BoolVar(&useTUI, "tui", false, "select with the built-in terminal finder")
+	rootCmd.AddCommand(grepCmd)
+}
+
+// projectMatch is a match found in a project
+type projectMatch struct {
+	Project string `json:"project"`
+	search.Match
+}
+
+// grepResult holds the matches of a project once it has been searched
+type grepResult struct {
+	matches []search.Match
+	err     error
+}
+
+// grepProjects searches the projects and prints or selects the matches
+func grepProjects(cmd *cobra.Command, args []string) error {
+	if grepJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	searcher, err := search.New(search.Options{
+		Pattern:    args[0],
+		IgnoreCase: grepIgnoreCase,
+		SkipDirs:   dirConfig().SkipDirs,
+		BuiltIn:    grepBuiltIn,
+	})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	projects := scanProjects()
+	results := searchProjects(searcher, projects)
+
+	var found []projectMatch
+	for i, project := range projects {
+		result := <-results[i]
+		if result.err != nil {
+			slog.Warn("search failed", "project", project, "err", result.err)
+			continue
+		}
+		if len(result.matches) == 0 {
+			continue
+		}
+		if !grepJSON && !grepSelect {
+			printProjectMatches(project, result.matches)
+		}
+		for _, m := range result.matches {
+			found = append(found, projectMatch{Project: project, Match: m})
+		}
+	}
+	slog.Debug("searched projects", "engine", searcher.Engine(), "projects", len(projects), "matches", len(found), "took", time.Since(start))
+
+	switch {
+	case grepJSON:
+		if found == nil {
+			found = []projectMatch{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(found)
+	case grepSelect:
+		return selectMatch(found)
+	case len(found) == 0:
+		return fmt.Errorf("no matches for %q", args[0])
+	}
+	return nil
+}
+
+// searchProjects searches up to --jobs projects at once. The result of
+// each project is sent on the channel of the same index, so they can be
+// printed in order as they come in.
+func searchProjects(searcher *search.Searcher, projects []string) []chan grepResult {
+	results := make([]chan grepResult, len(projects))
+	for i := range results {
+		results[i] = make(chan grepResult, 1)
+	}
+
+	sem := make(chan struct{}, grepJobs)
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			matches, err := searcher.Dir(projectPath(project))
+			results[i] <- grepResult{matches, err}
+		}()
+	}
+	go wg.Wait()
+	return results
+}
+
+// printProjectMatches prints the matches of a project under its name
+func printProjectMatches(project string, matches []search.Match) {
+	fmt.Println(project)
+	for _, m := range matches {
+		fmt.Printf("  %s:%d: %s\n", m.File, m.Line, m.Text)
+	}
+}
+
+// selectMatch shows the matches in the selector, records the chosen one
+// as the position of its project and opens the project
+func selectMatch(found []projectMatch) error {
+	if len(found) == 0 {
+		return fmt.Errorf("no matches")
+	}
+
+	candidates := make([]string, 0, len(found))
+	byLine := make(map[string]projectMatch, len(found))
+	for _, m := range found {
+		line := fmt.Sprintf("%s:%d: %s", filepath.Join(m.Project, m.File), m.Line, m.Text)
+		if _, seen := byLine[line]; !seen {
+			candidates = append(candidates, line)
+			byLine[line] = m
+		}
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	caches := openAnnotationCaches()
+	defer caches.Save()
+
+	selector := newProjectSelector(appConfig, candidates, mruList, remotes, caches)
+	_, selected, err := selector.Select(candidates)
+	if err != nil {
+		return fmt.Errorf("selection failed: %w", err)
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	m, ok := byLine[selected[0]]
+	if !ok {
+		return fmt.Errorf("unknown match: %s", selected[0])
+	}
+	dir := projectPath(m.Project)
+	pos := position.Position{File: filepath.Join(dir, m.File), Line: m.Line}
+	if err := openPositions().Set(dir, pos); err != nil {
+		return err
+	}
+	return openListed(mruList, remotes, m.Project, nil)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/marianozunino/code/v2/internal/tui"
+)
+
+// openHere replaces the process with the editor of a project running in
+// the current terminal. It only returns on failure.
+func openHere(selector *core.Selector, mruList *mru.MRUList, remotes *remote.Registry, project string) error {
+	if !tui.IsTerminal(os.Stdin) || !tui.IsTerminal(os.Stdout) {
+		return fmt.Errorf("--here needs an interactive terminal")
+	}
+
+	if ws, provider, ok := remotes.Lookup(project); ok {
+		if err := openHistory().Append(ws.Label()); err != nil {
+			return err
+		}
+		home, _ := os.UserHomeDir()
+		return execHere(home, provider.ConnectCommand(ws))
+	}
+
+	fullPath := filepath.Join(cfg.BaseDir, project)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
+	selector = selector.ForProject(fullPath)
+
+	argv, err := selector.HereCommand(fullPath, editorWindowTitle(selector, fullPath))
+	if err != nil {
+		return err
+	}
+
+	if err := selector.StartContainer(fullPath); err != nil {
+		return err
+	}
+	if err := selector.PrepareLayout(fullPath, editorWindowTitle(selector, fullPath)); err != nil {
+		return err
+	}
+
+	// Deferred saves never run once the process is replaced
+	if err := mruList.Update(project); err != nil {
+		return err
+	}
+	if err := mruList.Flush(); err != nil {
+		return err
+	}
+	// No window is tracked, the session ends with the process
+	if err := openHistory().Append(fullPath); err != nil {
+		return err
+	}
+
+	return execHere(fullPath, argv)
+}
+
+// execHere replaces the process with argv running in dir
+func execHere(dir string, argv []string) error {
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	if err := syscall.Exec(path, argv, os.Environ()); err != nil {
+		return fmt.Errorf("failed to run %s: %w", argv[0], err)
+	}
+	return nil
+}
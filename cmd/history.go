@@ -0,0 +1,176 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/spf13/cobra"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	historySince   string
+	historyUntil   string
+	historyOn      string
+	historyProject string
+	historyLimit   int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the log of opened projects",
+	Long: `Show the append-only log of opened projects with the time spent on each
+until the next launch.
+
+Bounds accept a date (2006-01-02) or a duration relative to now (48h):
+
+  code history --on tuesday
+  code history --since 2024-10-01 --until 2024-10-08
+  code history --since 72h --project api`,
+	Args: cobra.NoArgs,
+	RunE: showHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only show launches after this date or duration ago")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "only show launches before this date or duration ago")
+	historyCmd.Flags().StringVar(&historyOn, "on", "", "only show launches on this date or weekday (e.g. tuesday)")
+	historyCmd.Flags().StringVarP(&historyProject, "project", "p", "", "only show projects containing this text")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "show at most this many of the latest entries")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// showHistory prints the history log filtered by the command flags
+func showHistory(cmd *cobra.Command, args []string) error {
+	since, err := parseTimeBound(historySince)
+	if err != nil {
+		return err
+	}
+	until, err := parseTimeBound(historyUntil)
+	if err != nil {
+		return err
+	}
+	if historyOn != "" {
+		if since, err = parseDay(historyOn); err != nil {
+			return err
+		}
+		until = since.AddDate(0, 0, 1)
+	}
+
+	records, err := openHistory().Records()
+	if err != nil {
+		return err
+	}
+	records = history.Between(records, since, until)
+
+	if historyProject != "" {
+		filtered := records[:0]
+		for _, r := range records {
+			if strings.Contains(r.Project, historyProject) {
+				filtered = append(filtered, r)
+			}
+		}
+		records = filtered
+	}
+
+	if historyLimit > 0 && len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+
+	for _, r := range records {
+		duration := "-"
+		if r.Duration > 0 {
+			duration = formatDuration(r.Duration)
+		}
+		fmt.Printf("%s  %8s  %s\n", r.Time.Format("2006-01-02 15:04"), duration, displayPath(r.Project))
+	}
+	return nil
+}
+
+// formatDuration renders a duration with minute precision
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	s := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// parseTimeBound parses a date or a duration relative to now
+func parseTimeBound(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected %s or a duration", value, dateLayout)
+}
+
+// parseDay parses a date or a weekday name into the start of that day.
+// Weekday names refer to the most recent past occurrence.
+func parseDay(value string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	switch strings.ToLower(value) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for d := 1; d <= 7; d++ {
+		day := today.AddDate(0, 0, -d)
+		if strings.EqualFold(day.Weekday().String(), value) {
+			return day, nil
+		}
+	}
+
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day %q: expected %s or a weekday", value, dateLayout)
+	}
+	return t, nil
+}
+
+// displayPath shows a project relative to the base dir when possible
+func displayPath(project string) string {
+	rel, err := filepath.Rel(cfg.BaseDir, project)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return project
+	}
+	return rel
+}
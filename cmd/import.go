@@ -0,0 +1,134 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/position"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Merge state written by code export",
+	Long: `Merge state written by code export, read from a file or stdin when no
+file or "-" is given. Projects not listed yet are added and pins are kept,
+history entries the log lacks are added, and the more recent position of
+a project wins, so importing the same file twice changes nothing. Paths
+under the exported base dir are moved under this machine's base dir;
+projects that are not cloned here are skipped.`,
+	Example: `  code import ~/dotfiles/code-state.json`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    importState,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+// importState merges a state bundle into the state files
+func importState(cmd *cobra.Command, args []string) error {
+	bundle, err := readStateBundle(args)
+	if err != nil {
+		return err
+	}
+	if bundle.Version > stateVersion {
+		return fmt.Errorf("state version %d is newer than this code supports (%d)", bundle.Version, stateVersion)
+	}
+	rebase := rebaser(bundle.BaseDir)
+
+	for i := range bundle.Projects {
+		bundle.Projects[i].Path = rebase(bundle.Projects[i].Path)
+	}
+	added, missing, err := openMRU().Import(bundle.Projects)
+	if err != nil {
+		return err
+	}
+
+	for i := range bundle.History {
+		bundle.History[i].Project = rebase(bundle.History[i].Project)
+	}
+	entries, err := openHistory().Merge(bundle.History)
+	if err != nil {
+		return err
+	}
+
+	positions := make(map[string]position.Position, len(bundle.Positions))
+	for project, pos := range bundle.Positions {
+		pos.File = rebase(pos.File)
+		positions[rebase(project)] = pos
+	}
+	taken, err := openPositions().Merge(positions)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d projects, %d history entries and %d positions\n", added, entries, taken)
+	if len(missing) > 0 {
+		fmt.Printf("Skipped %d projects that are not cloned here:\n", len(missing))
+		for _, path := range missing {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+	return nil
+}
+
+// readStateBundle decodes the bundle of the file argument, or of stdin
+func readStateBundle(args []string) (stateBundle, error) {
+	var r io.Reader = os.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return stateBundle{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var bundle stateBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return stateBundle{}, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return bundle, nil
+}
+
+// rebaser returns a function moving paths under the exported base dir
+// under the current one. Other paths, such as remote workspace labels,
+// are kept.
+func rebaser(exportedBase string) func(string) string {
+	return func(path string) string {
+		if exportedBase == "" || !filepath.IsAbs(path) {
+			return path
+		}
+		rel, err := filepath.Rel(exportedBase, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return path
+		}
+		return filepath.Join(cfg.BaseDir, rel)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/window"
+	"github.com/spf13/cobra"
+)
+
+var killCmd = &cobra.Command{
+	Use:   "kill <name>",
+	Short: "Close the window and tmux session of a project",
+	Long: `Kill closes the editor window of a project through the window manager and
+kills its tmux session, shutting the project down in one go.
+
+The name is resolved like with "code open". Windows are only closed on
+window managers that support it; the tmux session is killed regardless.`,
+	Example: `  code kill api
+  code kill api --editor vscode`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE:              killProject,
+}
+
+func init() {
+	rootCmd.AddCommand(killCmd)
+	killCmd.Flags().StringVar(&editorName, "editor", "", "the editor profile the project was opened with")
+	killCmd.RegisterFlagCompletionFunc("editor", completeEditors)
+}
+
+// killProject closes the window and tmux session of a project
+func killProject(cmd *cobra.Command, args []string) error {
+	mruList := openMRU()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	project, err := core.ResolveProject(args[0], projects)
+	if err != nil {
+		return err
+	}
+
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	selector := core.NewSelector(appConfig)
+	if name := editorProfileName(); name != "" {
+		if err := selector.UseEditor(name); err != nil {
+			return err
+		}
+	}
+
+	var find windowFinder
+	var fullPath string
+	if ws, _, ok := remotes.Lookup(project); ok {
+		find = byTitle(fmt.Sprintf("%s ~ %s", ws.Provider, ws.Name))
+	} else {
+		fullPath = projectPath(project)
+		selector = selector.ForProject(fullPath)
+		find = editorWindow(selector, fullPath, editorWindowTitle(selector, fullPath))
+	}
+
+	killed := false
+
+	// The window goes first, while its tmux client still leads to it
+	backend := windowBackend()
+	if windowID, _ := find(backend); windowID != 0 {
+		closer, ok := backend.(window.Closer)
+		if !ok {
+			return fmt.Errorf("window backend %s cannot close windows", backend.Name())
+		}
+		if err := closer.CloseWindow(windowID); err != nil {
+			return fmt.Errorf("failed to close window: %w", err)
+		}
+		fmt.Printf("closed window of %s\n", project)
+		killed = true
+	}
+
+	if fullPath != "" {
+		if session, ok := projectSession(tmuxSessions(), selector.SessionNaming(), fullPath); ok {
+			if output, err := exec.Command("tmux", "kill-session", "-t", "="+session).CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to kill tmux session %s: %w: %s", session, err, output)
+			}
+			fmt.Printf("killed tmux session %s\n", session)
+			killed = true
+		}
+	}
+
+	if !killed {
+		return fmt.Errorf("%s has no open window or tmux session", project)
+	}
+	return nil
+}
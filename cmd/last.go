@@ -0,0 +1,84 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var lastCmd = &cobra.Command{
+	Use:   "last [-- editor args...]",
+	Short: "Reopen the most recently opened project",
+	Long: `Launch or focus the project opened last, without showing the selector,
+to get back to what you were doing from a key binding. Projects pinned
+but never opened do not count.
+
+Arguments after -- are passed to the editor like with code open.`,
+	Example: `  code last
+  code last --new-window`,
+	Args: cobra.ArbitraryArgs,
+	RunE: openLast,
+}
+
+func init() {
+	lastCmd.Flags().StringVar(&editorName, "editor", "", "open the project with a named editor profile from the selector file, or an editor preset")
+	lastCmd.Flags().BoolVar(&here, "here", false, "run the editor in the current terminal instead of opening a window")
+	lastCmd.Flags().BoolVar(&newWindow, "new-window", false, "open another window even when the project has one, with a session of its own")
+	lastCmd.Flags().BoolVar(&inContainer, "container", false, "run terminal editors inside the devcontainer of projects that have one")
+	lastCmd.Flags().BoolVar(&inCodespace, "codespace", false, "open the project in the most recently used codespace of its GitHub repository")
+	lastCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the editor command and window title instead of running them")
+	lastCmd.RegisterFlagCompletionFunc("editor", completeEditors)
+	rootCmd.AddCommand(lastCmd)
+}
+
+// openLast opens the project of the MRU list opened most recently
+func openLast(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 0 && len(args) > 0 {
+		return fmt.Errorf("last takes no project, editor arguments follow --")
+	}
+
+	project, err := lastOpened()
+	if err != nil {
+		return err
+	}
+	return openNamed(cmd, project, args)
+}
+
+// lastOpened returns the project of the MRU list with the latest open
+// time, or the first one when no open times are known
+func lastOpened() (string, error) {
+	mruList := openMRU()
+	projects := mruList.Items()
+	if len(projects) == 0 {
+		return "", fmt.Errorf("no recent projects, open one first")
+	}
+
+	last := projects[0]
+	for _, project := range projects[1:] {
+		if mruList.LastOpened(project).After(mruList.LastOpened(last)) {
+			last = project
+		}
+	}
+	return last, nil
+}
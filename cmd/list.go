@@ -0,0 +1,144 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// Tags attached to listed projects
+const (
+	tagPinned = "pinned"
+	tagRemote = "remote"
+)
+
+var (
+	listJSON     bool
+	listAbsolute bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [base-dir]",
+	Short: "Print the project list the selector shows",
+	Long: `Print the merged, deduplicated project list in the same order the
+selector shows it, one project per line relative to the base dir, for shell
+pipelines. --filter narrows it down like in the selector and --absolute
+prints full paths. With --json every project is printed with its absolute
+path, last opened time, tags and language for scripts and status bars.`,
+	Example: `  code list --filter api
+  code list --sort alpha --absolute | xargs -I{} git -C {} fetch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: listProjectsCmd,
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the list as JSON")
+	listCmd.Flags().BoolVar(&listAbsolute, "absolute", false, "print absolute paths of local projects")
+	listCmd.Flags().StringVarP(&filter, "filter", "f", "", "only print projects matching this fuzzy filter")
+	rootCmd.AddCommand(listCmd)
+}
+
+// listEntry is a project as printed by list --json
+type listEntry struct {
+	Path       string     `json:"path"`
+	AbsPath    string     `json:"abs_path,omitempty"`
+	LastOpened *time.Time `json:"last_opened,omitempty"`
+	Tags       []string   `json:"tags"`
+	Language   string     `json:"language,omitempty"`
+}
+
+// listProjectsCmd prints the project list
+func listProjectsCmd(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		cfg.BaseDir = args[0]
+	}
+	if err := core.ValidateSortMode(cfg.Sort); err != nil {
+		return err
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	if filter != "" {
+		projects = core.FilterProjects(filter, projects)
+	}
+
+	if !listJSON {
+		for _, project := range projects {
+			if _, _, ok := remotes.Lookup(project); listAbsolute && !ok {
+				project = projectPath(project)
+			}
+			fmt.Println(project)
+		}
+		return nil
+	}
+
+	entries := make([]listEntry, 0, len(projects))
+	for _, project := range projects {
+		entries = append(entries, newListEntry(project, mruList, remotes))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// newListEntry collects the details of a listed project
+func newListEntry(project string, mruList *mru.MRUList, remotes *remote.Registry) listEntry {
+	entry := listEntry{
+		Path: project,
+		Tags: []string{},
+	}
+
+	if _, _, ok := remotes.Lookup(project); ok {
+		entry.Tags = append(entry.Tags, tagRemote)
+		return entry
+	}
+
+	entry.AbsPath = projectPath(project)
+	entry.Language = core.DetectLanguage(entry.AbsPath)
+	if opened := mruList.LastOpened(project); !opened.IsZero() {
+		entry.LastOpened = &opened
+	}
+	if mruList.IsPinned(project) {
+		entry.Tags = append(entry.Tags, tagPinned)
+	}
+	return entry
+}
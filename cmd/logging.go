@@ -0,0 +1,79 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/marianozunino/code/v2/internal/logging"
+)
+
+// logLevelEnv sets the log level when neither --verbose nor --quiet is
+// given, e.g. CODE_LOG_LEVEL=debug
+const logLevelEnv = "CODE_LOG_LEVEL"
+
+var (
+	verbose  bool
+	quiet    bool
+	logLevel slog.LevelVar
+)
+
+// loggingFlags only change what is logged, not the settings
+var loggingFlags = map[string]bool{"verbose": true, "quiet": true}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log what is done and how long it takes")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log errors")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
+	logLevel.Set(slog.LevelWarn)
+	slog.SetDefault(slog.New(logging.New(stderr{}, &logLevel)))
+}
+
+// setupLogging sets the log level from the flags or $CODE_LOG_LEVEL.
+// Warnings and errors are logged by default, --verbose adds what is done
+// and its timings.
+func setupLogging() error {
+	level := slog.LevelWarn
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	case os.Getenv(logLevelEnv) != "":
+		parsed, err := logging.ParseLevel(os.Getenv(logLevelEnv))
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+	logLevel.Set(level)
+	return nil
+}
+
+// stderr writes to the current os.Stderr, which rofi mode points elsewhere
+type stderr struct{}
+
+func (stderr) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
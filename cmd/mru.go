@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var mruCmd = &cobra.Command{
+	Use:   "mru",
+	Short: "Manage the most-recently-used project list",
+}
+
+var mruPinCmd = &cobra.Command{
+	Use:               "pin <project>",
+	Short:             "Keep a project in the MRU list regardless of its size limit",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], true)
+	},
+}
+
+var mruUnpinCmd = &cobra.Command{
+	Use:               "unpin <project>",
+	Short:             "Let a pinned project be evicted from the MRU list again",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], false)
+	},
+}
+
+func init() {
+	mruCmd.AddCommand(mruPinCmd, mruUnpinCmd)
+	rootCmd.AddCommand(mruCmd)
+}
+
+// setPinned toggles the sticky flag of a project relative to the base dir
+func setPinned(project string, pinned bool) error {
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	if pinned {
+		if !isDirectory(projectPath(project)) {
+			return fmt.Errorf("not a directory: %s", projectPath(project))
+		}
+		return mruList.Pin(project)
+	}
+	return mruList.Unpin(project)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/daemon"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <name>|--random [filter] [-- editor args...]",
+	Short: "Open a project by name without the selector",
+	Long: `Open resolves a name against the project list and launches or focuses
+the project straight away, without showing the selector.
+
+The name is matched against project paths and their last element: an exact
+match wins, then a unique prefix, then the best fuzzy match.
+
+Arguments after -- are passed to the editor, as {{.ExtraArgs}} in the editor
+template or else at the end of the editor command.
+
+With --random a random project is opened instead, out of those matching
+the optional fuzzy filter, --tag (pinned, remote or a language such as go)
+and --not-opened-since, to revisit neglected projects.`,
+	Example: `  code open api
+  code open work/api
+  code open devpod:sandbox
+  code open api -- +150 main.go
+  code open --random --not-opened-since 720h
+  code open --random --tag go side/`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		names := len(args)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			names = dash
+		}
+		if openRandom && names > 1 {
+			return fmt.Errorf("accepts at most 1 filter before -- with --random, received %d", names)
+		}
+		if !openRandom && names != 1 {
+			return fmt.Errorf("accepts 1 project name before --, received %d", names)
+		}
+		if !openRandom && (cmd.Flags().Changed("tag") || cmd.Flags().Changed("not-opened-since")) {
+			return fmt.Errorf("--tag and --not-opened-since need --random")
+		}
+		return nil
+	},
+	ValidArgsFunction: completeProject,
+	RunE:              openByName,
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openCmd.Flags().StringVar(&editorName, "editor", "", "open the project with a named editor profile from the selector file, or an editor preset")
+	openCmd.Flags().BoolVar(&here, "here", false, "run the editor in the current terminal instead of opening a window")
+	openCmd.Flags().BoolVar(&newWindow, "new-window", false, "open another window even when the project has one, with a session of its own")
+	openCmd.Flags().BoolVar(&inContainer, "container", false, "run terminal editors inside the devcontainer of projects that have one")
+	openCmd.Flags().BoolVar(&inCodespace, "codespace", false, "open the project in the most recently used codespace of its GitHub repository")
+	openCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the editor command and window title instead of running them")
+	openCmd.Flags().BoolVar(&openRandom, "random", false, "open a random project, optionally matching a fuzzy filter")
+	openCmd.Flags().StringVar(&randomTag, "tag", "", "with --random, only pick projects with this tag: pinned, remote or a language")
+	openCmd.Flags().StringVar(&randomNotOpenedSince, "not-opened-since", "", "with --random, only pick projects not opened after this date or duration ago")
+	openCmd.RegisterFlagCompletionFunc("editor", completeEditors)
+	openCmd.RegisterFlagCompletionFunc("tag", fixedCompletions(tagPinned, tagRemote))
+}
+
+// openByName opens the project named by the first argument
+func openByName(cmd *cobra.Command, args []string) error {
+	if err := core.ValidateSortMode(cfg.Sort); err != nil {
+		return err
+	}
+
+	names, extraArgs := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		names, extraArgs = args[:dash], args[dash:]
+	}
+	if openRandom {
+		return openRandomProject(strings.Join(names, ""), extraArgs)
+	}
+	return openNamed(cmd, names[0], extraArgs)
+}
+
+// openNamed resolves a project name and launches it with extra editor
+// arguments, through the daemon when one runs
+func openNamed(cmd *cobra.Command, name string, extraArgs []string) error {
+	if !here && !dryRun && !inContainer && !inCodespace {
+		err := daemonOpen(cmd, name, extraArgs)
+		if !errors.Is(err, daemon.ErrNotRunning) && !errors.Is(err, daemon.ErrDeclined) {
+			return err
+		}
+		slog.Debug("opening without the daemon", "reason", err)
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	project, err := core.ResolveProject(name, projects)
+	if err != nil {
+		return err
+	}
+
+	return openListed(mruList, remotes, project, extraArgs)
+}
+
+// openListed launches or focuses a project of the project list without
+// the selector
+func openListed(mruList *mru.MRUList, remotes *remote.Registry, project string, extraArgs []string) error {
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	selector := core.NewSelector(appConfig)
+	selector.SetLaunchLog(cfg.LaunchLog)
+	if inContainer {
+		selector.UseContainer()
+	}
+	selector.AddEditorAnnotator(positionAnnotator(openPositions()))
+	selector.AddEditorAnnotator(sessionAnnotator(appConfig.SessionNaming))
+	selector.AddEditorAnnotator(editorContextAnnotator)
+	selector.SetExtraArgs(extraArgs)
+	if name := editorProfileName(); name != "" {
+		if err := selector.UseEditor(name); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		return printDryRun(selector, remotes, project, false)
+	}
+	return runAction(core.ActionOpen, selector, mruList, remotes, project)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/window"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	settingOverrides []string
+	selectorPreset   string
+	terminalPreset   string
+)
+
+// boundFlags are the flags of settings of ~/.code.yaml, by key
+var boundFlags = map[string]string{
+	"base_dir":       "base-dir",
+	"mru_file":       "mru-file",
+	"sort":           "sort",
+	"window_backend": "window-backend",
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.String("base-dir", "", "directory searched for projects")
+	flags.String("mru-file", "", "file of the most-recently-used project list")
+	flags.String("sort", "", "order of the project list: mru, frecency, alpha or recently-modified")
+	flags.String("window-backend", "", "window manager backend: auto, none or a backend name")
+	flags.StringVar(&selectorPreset, "selector", "", "selector preset to use instead of the one of the selector file")
+	flags.StringVar(&terminalPreset, "terminal", "", "terminal preset to use instead of the one of the selector file")
+	flags.StringArrayVar(&settingOverrides, "set", nil, "override a setting of either config file, e.g. --set format.path_style=short (repeatable)")
+
+	rootCmd.RegisterFlagCompletionFunc("sort", fixedCompletions(core.SortMRU, core.SortFrecency, core.SortAlpha, core.SortRecentlyModified))
+	rootCmd.RegisterFlagCompletionFunc("window-backend", fixedCompletions(window.Names()...))
+	rootCmd.RegisterFlagCompletionFunc("selector", fixedCompletions(core.SelectorPresetNames()...))
+	rootCmd.RegisterFlagCompletionFunc("terminal", fixedCompletions(core.TerminalPresetNames()...))
+
+	for key, flag := range boundFlags {
+		cobra.CheckErr(viper.BindPFlag(key, flags.Lookup(flag)))
+	}
+}
+
+// parseSetting splits a key=value override, reading the value as YAML so
+// that --set track_sessions=true or --set skip_dirs=[vendor] are typed
+func parseSetting(setting string) (string, any, error) {
+	key, text, ok := strings.Cut(setting, "=")
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid setting %q: want key=value", setting)
+	}
+
+	var value any
+	if err := yaml.Unmarshal([]byte(text), &value); err != nil {
+		return "", nil, fmt.Errorf("invalid value of %s: %w", key, err)
+	}
+	return key, value, nil
+}
+
+// applySettingOverrides sets the --set overrides of ~/.code.yaml settings,
+// which take precedence over the file, its includes and the profile
+func applySettingOverrides() error {
+	main := mainConfigSchema()
+	for _, setting := range settingOverrides {
+		key, value, err := parseSetting(setting)
+		if err != nil {
+			return err
+		}
+		if main.Has(key) {
+			viper.Set(key, value)
+		} else if !selectorConfigSchema().Has(key) {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// loadSelectorConfig loads the selector file with the --selector,
+// --terminal and --set overrides merged over it
+func loadSelectorConfig() (*core.Config, error) {
+	appConfig, err := core.LoadConfig(cfg.SelectorFile)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]any{}
+	if selectorPreset != "" {
+		overrides["selector"] = selectorPreset
+		overrides["selectors"] = []any{}
+	}
+	if terminalPreset != "" {
+		overrides["terminal"] = terminalPreset
+	}
+
+	main := mainConfigSchema()
+	for _, setting := range settingOverrides {
+		key, value, err := parseSetting(setting)
+		if err != nil {
+			return nil, err
+		}
+		if !main.Has(key) {
+			setNested(overrides, strings.Split(key, "."), value)
+		}
+	}
+
+	if err := appConfig.Override(overrides); err != nil {
+		return nil, err
+	}
+	return appConfig, nil
+}
+
+// setNested sets a value under a key path, creating the maps on the way
+func setNested(settings map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := settings[key].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			settings[key] = child
+		}
+		settings = child
+	}
+	settings[path[len(path)-1]] = value
+}
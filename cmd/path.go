@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path <project>",
+	Short: "Print the absolute path of a project",
+	Long: `Print the absolute path of the project a name refers to, resolved like
+code open does: an exact name, a unique prefix, or else the best fuzzy
+match. Nothing is opened and the recent projects are left alone.`,
+	Example: `  code path api
+  cd "$(code path api)"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE:              printPathOf,
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+}
+
+// printPathOf prints the path of the project named by the argument
+func printPathOf(cmd *cobra.Command, args []string) error {
+	project, err := resolveLocalProject(args[0])
+	if err != nil {
+		return err
+	}
+	path, err := filepath.Abs(projectPath(project))
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/marianozunino/code/v2/internal/position"
+	"github.com/spf13/cobra"
+)
+
+var positionProject string
+
+var positionCmd = &cobra.Command{
+	Use:   "position",
+	Short: "Record and query the last editor position per project",
+	Long: `Record and query the last file and line opened in each project.
+
+Editors report their position through "code position set", for example
+from a Neovim autocommand:
+
+  vim.api.nvim_create_autocmd("VimLeavePre", {
+    callback = function()
+      local file = vim.api.nvim_buf_get_name(0)
+      if file ~= "" then
+        vim.fn.system({ "code", "position", "set", file, tostring(vim.fn.line(".")) })
+      end
+    end,
+  })
+
+The position is exposed to editor templates as {{.LastFile}} and {{.LastLine}}.`,
+}
+
+var positionSetCmd = &cobra.Command{
+	Use:   "set <file> [line]",
+	Short: "Record the current file and line of a project",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  setPosition,
+}
+
+var positionGetCmd = &cobra.Command{
+	Use:               "get [project]",
+	Short:             "Print the last recorded file and line of a project",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE:              getPosition,
+}
+
+func init() {
+	positionSetCmd.Flags().StringVarP(&positionProject, "project", "p", "", "project directory (default is the repository containing the file)")
+	positionCmd.AddCommand(positionSetCmd, positionGetCmd)
+	rootCmd.AddCommand(positionCmd)
+}
+
+// setPosition stores the position reported by an editor
+func setPosition(cmd *cobra.Command, args []string) error {
+	file, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	line := 1
+	if len(args) > 1 {
+		if line, err = strconv.Atoi(args[1]); err != nil || line < 1 {
+			return fmt.Errorf("invalid line: %s", args[1])
+		}
+	}
+
+	project := positionProject
+	if project == "" {
+		project = findProjectRoot(filepath.Dir(file))
+	}
+	if project, err = filepath.Abs(project); err != nil {
+		return err
+	}
+
+	return openPositions().Set(project, position.Position{File: file, Line: line})
+}
+
+// getPosition prints the stored position of a project
+func getPosition(cmd *cobra.Command, args []string) error {
+	project, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		project = projectPath(args[0])
+	}
+	project = findProjectRoot(project)
+
+	pos, ok, err := openPositions().Get(project)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no position recorded for %s", project)
+	}
+
+	fmt.Printf("%s:%d\n", pos.File, pos.Line)
+	return nil
+}
+
+// findProjectRoot walks up from dir to the nearest git repository,
+// returning dir itself when it is not inside one
+func findProjectRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// positionAnnotator exposes the last editor position to editor templates
+func positionAnnotator(store *position.Store) func(dir string, data map[string]string) {
+	return func(dir string, data map[string]string) {
+		data["LastFile"] = ""
+		data["LastLine"] = ""
+
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		pos, ok, err := store.Get(dir)
+		if err != nil || !ok {
+			return
+		}
+		if _, err := os.Stat(pos.File); err != nil {
+			return // The file was deleted or renamed since
+		}
+		data["LastFile"] = pos.File
+		data["LastLine"] = strconv.Itoa(pos.Line)
+	}
+}
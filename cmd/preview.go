@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+const previewReadmeLines = 15
+
+var previewCmd = &cobra.Command{
+	Use:    "preview <project>",
+	Short:  "Print preview text for a project (used by selector preview panes)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   showPreview,
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+}
+
+// showPreview prints the preview of a project path or formatted selector entry
+func showPreview(cmd *cobra.Command, args []string) error {
+	project := args[0]
+	if !isDirectory(projectPath(project)) {
+		appConfig, err := loadSelectorConfig()
+		if err != nil {
+			return err
+		}
+		project = core.NewSelector(appConfig).ExtractPath(project)
+		if !isDirectory(projectPath(project)) {
+			// Short path styles drop the parent directories
+			remotes, _ := remote.NewRegistry(nil)
+			if projects, err := listProjects(openMRU(), remotes); err == nil {
+				project = newPathStyler(appConfig, projects, remotes).Resolve(project)
+			}
+		}
+	}
+
+	dir := projectPath(project)
+	if !isDirectory(dir) {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+
+	return writePreview(os.Stdout, project, dir)
+}
+
+// writePreview renders the language, last-opened time, recent commits and
+// README head of a project
+func writePreview(w io.Writer, project, dir string) error {
+	header := []string{filepath.Base(dir)}
+	if language := core.DetectLanguage(dir); language != "" {
+		header = append(header, language)
+	}
+	if opened := core.HumanizeSince(openMRU().LastOpened(project)); opened != "" {
+		header = append(header, "opened "+opened)
+	}
+	fmt.Fprintln(w, strings.Join(header, " · "))
+	fmt.Fprintln(w)
+
+	if log, err := exec.Command("git", "-C", dir, "log", "-3", "--format=%h %s (%cr)").Output(); err == nil && len(log) > 0 {
+		fmt.Fprintf(w, "%s\n", strings.TrimRight(string(log), "\n"))
+		fmt.Fprintln(w)
+	}
+
+	return writeReadmeHead(w, dir, previewReadmeLines)
+}
+
+// writeReadmeHead prints the first lines of the project README, if any
+func writeReadmeHead(w io.Writer, dir string, lines int) error {
+	matches, _ := filepath.Glob(filepath.Join(dir, "[Rr][Ee][Aa][Dd][Mm][Ee]*"))
+	if len(matches) == 0 {
+		return nil
+	}
+
+	file, err := os.Open(matches[0])
+	if err != nil {
+		return nil // An unreadable README is not worth failing the preview
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < lines && scanner.Scan(); i++ {
+		fmt.Fprintln(w, scanner.Text())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// profileEnv names the profile when --profile is not given
+const profileEnv = "CODE_PROFILE"
+
+// profileStateKeys are the state and cache files kept apart per profile,
+// unless the profile sets them itself
+var profileStateKeys = []string{
+	"mru_file",
+	"history_file",
+	"sessions_file",
+	"positions_file",
+	"git_status_cache",
+	"description_cache",
+}
+
+// applyProfile merges the settings of a profile from the profiles section
+// over the top level ones. They are merged into the config file layer, so
+// reading the file again drops them.
+func applyProfile(name string) error {
+	profile := viper.Sub("profiles." + name)
+	if profile == nil {
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(profileNames(), ", "))
+	}
+
+	settings := profile.AllSettings()
+	for _, key := range profileStateKeys {
+		if !profile.IsSet(key) && viper.GetString(key) != "" {
+			settings[key] = profileFile(viper.GetString(key), name)
+		}
+	}
+	return viper.MergeConfigMap(settings)
+}
+
+// profileNames returns the profiles defined in the config file in order
+func profileNames() []string {
+	var names []string
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileFile returns the file of a profile next to a shared one, e.g.
+// ~/.code_mru.work or git-status.work.json
+func profileFile(path, profile string) string {
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(strings.TrimPrefix(base, "."))
+	return dir + strings.TrimSuffix(base, ext) + "." + profile + ext
+}
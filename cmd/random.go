@@ -0,0 +1,116 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+)
+
+var (
+	openRandom           bool
+	randomTag            string
+	randomNotOpenedSince string
+)
+
+// openRandomProject opens a random project of the list, narrowed down by
+// an optional fuzzy filter, --tag and --not-opened-since
+func openRandomProject(pattern string, extraArgs []string) error {
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+	if pattern != "" {
+		projects = core.FilterProjects(pattern, projects)
+	}
+
+	candidates, err := randomCandidates(mruList, remotes, projects)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no project matches")
+	}
+
+	project := candidates[rand.IntN(len(candidates))]
+	slog.Info("picked a project", "project", project, "candidates", len(candidates))
+	return openListed(mruList, remotes, project, extraArgs)
+}
+
+// randomCandidates returns the projects with the --tag tag that were not
+// opened since --not-opened-since
+func randomCandidates(mruList *mru.MRUList, remotes *remote.Registry, projects []string) ([]string, error) {
+	notSince, err := parseTimeBound(randomNotOpenedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastOpened map[string]time.Time
+	if !notSince.IsZero() {
+		records, err := openHistory().Records()
+		if err != nil {
+			return nil, err
+		}
+		lastOpened = history.LastOpened(records)
+	}
+
+	var candidates []string
+	for _, project := range projects {
+		entry := newListEntry(project, mruList, remotes)
+		if randomTag != "" && !slices.Contains(entry.Tags, randomTag) && !strings.EqualFold(entry.Language, randomTag) {
+			continue
+		}
+		if !notSince.IsZero() {
+			opened := lastOpened[project]
+			if ws, _, ok := remotes.Lookup(project); ok {
+				opened = lastOpened[ws.Label()]
+			} else if last := lastOpened[entry.AbsPath]; last.After(opened) {
+				opened = last
+			}
+			if entry.LastOpened != nil && entry.LastOpened.After(opened) {
+				opened = *entry.LastOpened
+			}
+			if opened.After(notSince) {
+				continue
+			}
+		}
+		candidates = append(candidates, project)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recentLong     bool
+	recentAbsolute bool
+	recentLimit    int
+)
+
+var recentCmd = &cobra.Command{
+	Use:   "recent",
+	Short: "Print the most-recently-used projects",
+	Long: `Print the MRU list, most recent first, without running a selector.
+With --long every project is shown with when it was last opened and how
+many times it was opened according to the history log.`,
+	Example: `  code recent -n 5
+  code recent --long
+  code recent --absolute | head -1`,
+	Args: cobra.NoArgs,
+	RunE: printRecent,
+}
+
+func init() {
+	recentCmd.Flags().BoolVarP(&recentLong, "long", "l", false, "show the last opened time and open count")
+	recentCmd.Flags().BoolVar(&recentAbsolute, "absolute", false, "print absolute paths")
+	recentCmd.Flags().IntVarP(&recentLimit, "limit", "n", 0, "show at most this many projects")
+	rootCmd.AddCommand(recentCmd)
+}
+
+// printRecent prints the MRU list
+func printRecent(cmd *cobra.Command, args []string) error {
+	mruList := openMRU()
+	projects := mruList.Items()
+	if recentLimit > 0 && len(projects) > recentLimit {
+		projects = projects[:recentLimit]
+	}
+
+	var opens map[string]int
+	if recentLong {
+		records, err := openHistory().Records()
+		if err != nil {
+			return err
+		}
+		opens = make(map[string]int)
+		for _, r := range records {
+			opens[r.Project]++
+		}
+	}
+
+	for _, project := range projects {
+		path := project
+		if recentAbsolute {
+			path = projectPath(project)
+		}
+		if !recentLong {
+			fmt.Println(path)
+			continue
+		}
+
+		opened := "-"
+		if t := mruList.LastOpened(project); !t.IsZero() {
+			opened = t.Format("2006-01-02 15:04")
+		}
+		pinned := ""
+		if mruList.IsPinned(project) {
+			pinned = "  (pinned)"
+		}
+		fmt.Printf("%-16s  %4d  %s%s\n", opened, opens[projectPath(project)], path, pinned)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// configCheckInterval is how often long-running commands look for changes
+// to the config file
+const configCheckInterval = 2 * time.Second
+
+// configMu guards cfg while long-running commands reload it
+var configMu sync.Mutex
+
+// configWatcher notices changes to the config file and the files it
+// includes by their modification times
+type configWatcher struct {
+	modTimes map[string]time.Time
+}
+
+// newConfigWatcher watches the config file in use, if any
+func newConfigWatcher() *configWatcher {
+	return &configWatcher{modTimes: configModTimes()}
+}
+
+// configModTimes returns the modification times of the config files, zero
+// for missing ones
+func configModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return modTimes
+	}
+
+	for _, file := range append([]string{path}, includedFiles...) {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		} else {
+			modTimes[file] = time.Time{}
+		}
+	}
+	return modTimes
+}
+
+// changed reports whether a config file was written since the last call
+func (w *configWatcher) changed() bool {
+	modTimes := configModTimes()
+	if maps.EqualFunc(modTimes, w.modTimes, time.Time.Equal) {
+		return false
+	}
+	w.modTimes = modTimes
+	return true
+}
+
+// reloadConfig reads the config file again and replaces cfg. A file that
+// fails to parse leaves the previous settings in place.
+func reloadConfig() error {
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := mergeIncludes(); err != nil {
+		return err
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		return err
+	}
+	if err := loadEnvFile(config.EnvFile); err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	cfg = config
+	configMu.Unlock()
+	return nil
+}
+
+// reloadIfChanged reloads the config when its file changed and reports
+// whether it did
+func (w *configWatcher) reloadIfChanged() bool {
+	if !w.changed() {
+		return false
+	}
+	if err := reloadConfig(); err != nil {
+		slog.Warn("keeping previous config", "err", err)
+		return false
+	}
+	slog.Info("reloaded config file", "path", viper.ConfigFileUsed())
+	return true
+}
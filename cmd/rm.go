@@ -0,0 +1,214 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/gitstatus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rmDelete  bool
+	rmArchive bool
+	rmForce   bool
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Forget a project, optionally deleting or archiving its directory",
+	Long: `Remove a project from the MRU list, its pin, and the git status,
+description and position caches. The name is resolved like with "code open",
+except that --archive and --delete take only an exact name or a unique
+prefix, never a fuzzy match, and refuse the base dir and its parents.
+
+The directory is left alone unless --archive moves it to archive_dir or
+--delete removes it. Deleting is refused while the repository has
+uncommitted changes, commits no remote has or stashed changes, or is not a
+git repository at all, unless --force is given.`,
+	Example: `  code rm old-api
+  code rm old-api --archive
+  code rm scratch --delete --force`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProject,
+	RunE:              removeProject,
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().BoolVar(&rmDelete, "delete", false, "delete the project directory")
+	rmCmd.Flags().BoolVar(&rmArchive, "archive", false, "move the project directory to archive_dir")
+	rmCmd.Flags().BoolVar(&rmForce, "force", false, "delete even with uncommitted or unpushed changes")
+	rmCmd.MarkFlagsMutuallyExclusive("delete", "archive")
+}
+
+// removeProject forgets a project and deletes or archives its directory
+func removeProject(cmd *cobra.Command, args []string) error {
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	// Deleting or archiving a fuzzy match of a typo would touch a project
+	// the user never named
+	resolve := core.ResolveProject
+	if rmDelete || rmArchive {
+		resolve = core.ResolveProjectStrict
+	}
+	project, err := resolve(args[0], projects)
+	if err != nil {
+		return err
+	}
+	if _, _, ok := remotes.Lookup(project); ok {
+		return fmt.Errorf("%s is a remote workspace, remove it with its provider", project)
+	}
+	dir := projectPath(project)
+	if (rmDelete || rmArchive) && containsDir(dir, cfg.BaseDir) {
+		return fmt.Errorf("%s contains the base dir %s, refusing to move or delete it", dir, cfg.BaseDir)
+	}
+
+	// Check before forgetting anything so a refused delete changes nothing
+	if rmDelete && !rmForce {
+		if err := checkDeletable(dir); err != nil {
+			return err
+		}
+	}
+
+	if err := mruList.Remove(project); err != nil {
+		return fmt.Errorf("failed to update MRU list: %w", err)
+	}
+	caches := openAnnotationCaches()
+	caches.git.Delete(dir)
+	caches.descriptions.Delete(dir)
+	caches.Save()
+	if err := openPositions().Delete(dir); err != nil {
+		return err
+	}
+	fmt.Printf("removed %s from the project list\n", project)
+
+	switch {
+	case rmDelete:
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", dir, err)
+		}
+		fmt.Printf("deleted %s\n", dir)
+	case rmArchive:
+		archived, err := archiveProject(project, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("moved %s to %s\n", dir, archived)
+	}
+	return nil
+}
+
+// checkDeletable refuses directories that are not git repositories or hold
+// work that exists nowhere else
+func checkDeletable(dir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	defer cancel()
+
+	status, err := gitstatus.Read(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("cannot tell whether %s is safe to delete (%w), use --force", dir, err)
+	}
+	if status.Dirty {
+		return fmt.Errorf("%s has uncommitted changes, use --force to delete it anyway", dir)
+	}
+
+	unpushed, err := gitstatus.Unpushed(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("cannot tell whether %s is safe to delete (%w), use --force", dir, err)
+	}
+	if unpushed > 0 {
+		return fmt.Errorf("%s has %d unpushed commits, use --force to delete it anyway", dir, unpushed)
+	}
+
+	stashes, err := gitstatus.Stashes(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("cannot tell whether %s is safe to delete (%w), use --force", dir, err)
+	}
+	if stashes > 0 {
+		return fmt.Errorf("%s has %d stashed changes, use --force to delete it anyway", dir, stashes)
+	}
+	return nil
+}
+
+// containsDir reports whether dir is other or one of its parents
+func containsDir(dir, other string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return true
+	}
+	other, err = filepath.Abs(other)
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(dir, other)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// archiveProject moves a project directory under archive_dir, keeping its
+// path relative to the base dir, and returns where it went
+func archiveProject(project, dir string) (string, error) {
+	if cfg.ArchiveDir == "" {
+		return "", fmt.Errorf("no archive_dir configured")
+	}
+
+	archiveDir, err := expandHome(cfg.ArchiveDir)
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(archiveDir, project)
+	if _, err := os.Stat(dest); err == nil {
+		dest += "-" + time.Now().Format("20060102-150405")
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive dir: %w", err)
+	}
+
+	if err := os.Rename(dir, dest); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return "", fmt.Errorf("archive_dir %s must be on the same filesystem as %s", archiveDir, dir)
+		}
+		return "", fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return dest, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// Values of ROFI_RETV, telling a script mode why it was called
+const (
+	rofiInitial     = 0
+	rofiSelected    = 1
+	rofiCustomEntry = 2
+	rofiCustomKey1  = 10 // kb-custom-1, forgets the project
+	rofiCustomKey2  = 11 // kb-custom-2, opens a terminal
+)
+
+var rofiModeCmd = &cobra.Command{
+	Use:   "rofi-mode [entry]",
+	Short: "Run as a rofi script mode",
+	Long: `Rofi-mode implements rofi's script mode protocol so the launcher can be
+embedded as a rofi mode:
+
+  rofi -show projects -modi "projects:code rofi-mode"
+
+Selecting an entry opens the project, typing a name that is not listed
+opens the best match. kb-custom-1 (Alt+1) removes the highlighted project
+from the MRU list and kb-custom-2 (Alt+2) opens just a terminal in it.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: rofiMode,
+}
+
+func init() {
+	rootCmd.AddCommand(rofiModeCmd)
+}
+
+// rofiMode prints the entries for rofi or handles the chosen one
+func rofiMode(cmd *cobra.Command, args []string) error {
+	if err := core.ValidateSortMode(cfg.Sort); err != nil {
+		return err
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	caches := openAnnotationCaches()
+	defer caches.Save()
+
+	selector := newProjectSelector(appConfig, projects, mruList, remotes, caches)
+
+	retv, _ := strconv.Atoi(os.Getenv("ROFI_RETV"))
+	if retv == rofiInitial || len(args) == 0 {
+		return printRofiEntries(selector, mruList, remotes)
+	}
+
+	project := os.Getenv("ROFI_INFO")
+	if project == "" {
+		project = newPathStyler(appConfig, projects, remotes).Resolve(selector.ExtractPath(args[0]))
+	}
+
+	switch retv {
+	case rofiCustomKey1:
+		if err := runAction(core.ActionForget, selector, mruList, remotes, project); err != nil {
+			return err
+		}
+		return printRofiEntries(selector, mruList, remotes) // Keep rofi open
+	case rofiCustomEntry:
+		if project, err = core.ResolveProject(args[0], projects); err != nil {
+			return err
+		}
+	}
+
+	// Rofi waits for the script's output to close, which the launched
+	// terminal would otherwise inherit
+	detachFromRofi()
+
+	if retv == rofiCustomKey2 {
+		return runAction(core.ActionTerminal, selector, mruList, remotes, project)
+	}
+	return runAction(core.ActionOpen, selector, mruList, remotes, project)
+}
+
+// printRofiEntries prints the mode options and one entry per project,
+// carrying the project in the info field so no extraction is needed
+func printRofiEntries(selector *core.Selector, mruList *mru.MRUList, remotes *remote.Registry) error {
+	projects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("\x00prompt\x1fProject\n")
+	fmt.Print("\x00use-hot-keys\x1ftrue\n")
+	for i, title := range selector.FormatTitles(projects) {
+		fmt.Printf("%s\x00info\x1f%s\n", title, projects[i])
+	}
+	return nil
+}
+
+// detachFromRofi points stdout and stderr at /dev/null
+func detachFromRofi() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	os.Stdout = devNull
+	os.Stderr = devNull
+}
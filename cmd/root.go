@@ -23,34 +23,83 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/marianozunino/code/v2/internal/backup"
 	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/daemon"
+	"github.com/marianozunino/code/v2/internal/describe"
+	"github.com/marianozunino/code/v2/internal/gitstatus"
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/position"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/marianozunino/code/v2/internal/stats"
+	"github.com/marianozunino/code/v2/internal/window"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	BaseDir      string `mapstructure:"base_dir"`
-	MruFile      string `mapstructure:"mru_file"`
-	SelectorFile string `mapstructure:"selector_file"`
+	BaseDir           string        `mapstructure:"base_dir"`
+	MruFile           string        `mapstructure:"mru_file"`
+	HistoryFile       string        `mapstructure:"history_file"`
+	SelectorFile      string        `mapstructure:"selector_file"`
+	BackupDir         string        `mapstructure:"backup_dir"`
+	BackupKeep        int           `mapstructure:"backup_keep"`
+	BackupMaxAge      time.Duration `mapstructure:"backup_max_age"`
+	RemoteProviders   []string      `mapstructure:"remote_providers"`
+	SSHProjects       []string      `mapstructure:"ssh_projects"`
+	Forges            []ForgeConfig `mapstructure:"forges"`
+	BringWindows      bool          `mapstructure:"bring_windows"`
+	WindowBackend     string        `mapstructure:"window_backend"`
+	WindowWaitTimeout time.Duration `mapstructure:"window_wait_timeout"`
+	WindowFindTimeout time.Duration `mapstructure:"window_find_timeout"`
+	TrackSessions     bool          `mapstructure:"track_sessions"`
+	SessionsFile      string        `mapstructure:"sessions_file"`
+	PositionsFile     string        `mapstructure:"positions_file"`
+	MruOnFocus        bool          `mapstructure:"mru_on_focus"`
+	MruFocusDelay     time.Duration `mapstructure:"mru_focus_delay"`
+	GitStatusCache    string        `mapstructure:"git_status_cache"`
+	DescriptionCache  string        `mapstructure:"description_cache"`
+	AnnotationBudget  time.Duration `mapstructure:"annotation_budget"`
+	Sort              string        `mapstructure:"sort"`
+	LaunchLog         string        `mapstructure:"launch_log"`
+	EditorProfile     string        `mapstructure:"editor_profile"`
+	ScanDepth         int           `mapstructure:"scan_depth"`
+	SkipDirs          []string      `mapstructure:"skip_dirs"`
+	Dirs              []DirConfig   `mapstructure:"dirs"`
+	Include           []string      `mapstructure:"include"`
+	EnvFile           string        `mapstructure:"env_file"`
+	CloneLayout       string        `mapstructure:"clone_layout"`
+	StatsFile         string        `mapstructure:"stats_file"`
+	DaemonSocket      string        `mapstructure:"daemon_socket"`
+	ArchiveDir        string        `mapstructure:"archive_dir"`
+	CodespaceMode     string        `mapstructure:"codespace_mode"`
+	WorktreeLayout    string        `mapstructure:"worktree_layout"`
 }
 
-const (
-	maxWaitTime    = 2 * time.Second
-	initialBackoff = 100 * time.Millisecond
-	backoffFactor  = 2
-)
-
 var (
 	cfgFile      string
 	cfg          Config
 	baseDir      string
 	selectorFile string
+	profile      string
+	useTUI       bool
+	filter       string
+	editorName   string
+	dryRun       bool
+	here         bool
+	newWindow    bool
+	inContainer  bool
+	printPath    bool
 )
 
 var rootCmd = &cobra.Command{
@@ -69,104 +118,758 @@ func Execute() error {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.code.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "settings profile from the profiles section of the config file (default $CODE_PROFILE)")
 	rootCmd.PersistentFlags().StringVarP(&selectorFile, "selector-file", "s", "", "yaml config file that defines the project selector")
+	rootCmd.Flags().BoolVar(&useTUI, "tui", false, "select the project with the built-in terminal finder")
+	rootCmd.Flags().StringVarP(&filter, "filter", "f", "", "fuzzy-filter the project list, opening the project directly when only one matches")
+	rootCmd.Flags().StringVar(&editorName, "editor", "", "open the project with a named editor profile from the selector file, or an editor preset")
+	rootCmd.Flags().BoolVar(&here, "here", false, "run the editor in the current terminal instead of opening a window")
+	rootCmd.Flags().BoolVar(&newWindow, "new-window", false, "open another window even when the project has one, with a session of its own")
+	rootCmd.Flags().BoolVar(&inContainer, "container", false, "run terminal editors inside the devcontainer of projects that have one")
+	rootCmd.Flags().BoolVar(&inCodespace, "codespace", false, "open the project in the most recently used codespace of its GitHub repository")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the selector and editor commands and the window title instead of running them")
+	rootCmd.Flags().BoolVar(&printPath, "print-path", false, "print the directory of the selected project instead of opening it, see shell-init")
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	rootCmd.RegisterFlagCompletionFunc("editor", completeEditors)
 }
 
 func initConfig() {
+	if err := setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	home, err := os.UserHomeDir()
+	cobra.CheckErr(err)
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
 		viper.AddConfigPath(home)
 		viper.SetConfigType("yaml")
 		viper.SetConfigName(".code")
-		viper.SetDefault("base_dir", filepath.Join(home, "Dev"))
-		viper.SetDefault("mru_file", filepath.Join(home, ".code_mru"))
 	}
 
+	viper.SetDefault("base_dir", filepath.Join(home, "Dev"))
+	viper.SetDefault("mru_file", filepath.Join(home, ".code_mru"))
+	viper.SetDefault("history_file", filepath.Join(home, ".code_history"))
+	viper.SetDefault("sessions_file", filepath.Join(home, ".code_sessions"))
+	viper.SetDefault("positions_file", filepath.Join(home, ".code_positions"))
+	viper.SetDefault("archive_dir", filepath.Join(home, ".code_archive"))
+	viper.SetDefault("backup_dir", backup.DefaultDir())
+	viper.SetDefault("backup_keep", 5)
+	viper.SetDefault("backup_max_age", 30*24*time.Hour)
+	viper.SetDefault("mru_focus_delay", 10*time.Second)
+	viper.SetDefault("git_status_cache", gitstatus.DefaultCacheFile())
+	viper.SetDefault("description_cache", describe.DefaultCacheFile())
+	viper.SetDefault("annotation_budget", 500*time.Millisecond)
+	viper.SetDefault("sort", core.SortMRU)
+	viper.SetDefault("launch_log", core.DefaultLaunchLog())
+	viper.SetDefault("stats_file", stats.DefaultFile())
+	viper.SetDefault("daemon_socket", daemon.DefaultSocket())
+	viper.SetDefault("window_wait_timeout", 2*time.Second)
+	viper.SetDefault("window_find_timeout", time.Second)
+	viper.SetDefault("clone_layout", defaultCloneLayout)
+	viper.SetDefault("codespace_mode", codespaceSSH)
+	viper.SetDefault("worktree_layout", defaultWorktreeLayout)
+
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		slog.Info("using config file", "path", viper.ConfigFileUsed())
+		if err := mergeIncludes(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	if err := viper.Unmarshal(&cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing config: %v\n", err)
+	if err := applySettingOverrides(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
-		cfg.BaseDir = os.Args[1]
+	if profile == "" {
+		profile = os.Getenv(profileEnv)
+	}
+
+	config, err := readConfig()
+	if err == nil {
+		err = loadEnvFile(config.EnvFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg = config
+}
+
+// includedFiles are the files the config file includes, in the order read
+var includedFiles []string
+
+// mergeIncludes merges the files in the include list of the config file
+// under its own settings
+func mergeIncludes() error {
+	include := viper.GetStringSlice("include")
+	if len(include) == 0 {
+		includedFiles = nil
+		return nil
+	}
+
+	path := viper.ConfigFileUsed()
+	settings, files, err := core.ReadIncludes(path, include)
+	if err != nil {
+		return err
+	}
+
+	// The config layer holds the file as read, read it on its own to put
+	// it back over the included settings
+	own := viper.New()
+	own.SetConfigFile(path)
+	if err := own.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return err
+	}
+	if err := viper.MergeConfigMap(own.AllSettings()); err != nil {
+		return err
+	}
+	includedFiles = files
+	return nil
+}
+
+// readConfig builds the settings from the config file as last read, the
+// profile and the flags
+func readConfig() (Config, error) {
+	var config Config
+	if profile != "" {
+		if err := applyProfile(profile); err != nil {
+			return config, err
+		}
+	}
+
+	if err := viper.Unmarshal(&config); err != nil {
+		return config, fmt.Errorf("failed to parse config: %w", err)
 	}
 
 	if selectorFile != "" {
-		cfg.SelectorFile = selectorFile
+		config.SelectorFile = selectorFile
 	}
+	return config, nil
 }
 
 // launchProject handles the project selection and launching process.
 func launchProject(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), maxWaitTime)
-	defer cancel()
+	if len(args) > 0 {
+		cfg.BaseDir = args[0]
+	}
+	if err := core.ValidateSortMode(cfg.Sort); err != nil {
+		return err
+	}
 
-	mruList := core.NewMRUList(cfg.MruFile, cfg.BaseDir)
-	defer mruList.Close() // Ensure MRU is saved on exit
+	mruList := openMRU()
+	defer mruList.Flush() // Ensure MRU is saved on exit
 
-	finder := &core.ProjectFinder{}
-	allProjects := finder.FindProjects(cfg.BaseDir)
+	if cfg.TrackSessions {
+		// Close sessions whose window disappeared since the last run
+		openTracker().Reap(windowAlive, openHistory())
+	}
 
-	uniqueProjects := core.RemoveDuplicates(append(mruList.Items(), allProjects...))
-	if len(uniqueProjects) == 0 {
-		return fmt.Errorf("no projects found in %s", cfg.BaseDir)
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	allProjects, err := listProjects(mruList, remotes)
+	if err != nil {
+		return err
+	}
+	uniqueProjects := allProjects
+	if filter != "" {
+		if uniqueProjects = core.FilterProjects(filter, uniqueProjects); len(uniqueProjects) == 0 {
+			return fmt.Errorf("%w: %s", core.ErrNoMatch, filter)
+		}
 	}
 
 	// Load configuration
-	appConfig, err := core.LoadConfig(cfg.SelectorFile)
+	appConfig, err := loadSelectorConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	selector := core.NewSelector(appConfig)
-	selectedProject, err := selector.Select(uniqueProjects)
+	caches := openAnnotationCaches()
+	defer caches.Save()
+
+	selector := newProjectSelector(appConfig, allProjects, mruList, remotes, caches)
+	if name := editorProfileName(); name != "" {
+		if err := selector.UseEditor(name); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		// Show the editor command for the project the filter picks, or else
+		// the first one of the list
+		return printDryRun(selector, remotes, uniqueProjects[0], filter == "" || len(uniqueProjects) > 1)
+	}
+	if filter != "" && len(uniqueProjects) == 1 {
+		// Nothing left to choose from
+		return runAction(core.ActionOpen, selector, mruList, remotes, uniqueProjects[0])
+	}
+
+	action, selectedProjects, err := selector.Select(uniqueProjects)
 	if err != nil {
 		return fmt.Errorf("project selection failed: %w", err)
 	}
-	if selectedProject == "" {
-		return nil
+	if printPath && len(selectedProjects) > 1 {
+		// A shell can only change into one of them
+		selectedProjects = selectedProjects[:1]
+	}
+
+	// A window that did not show up does not hold up the other projects
+	var timedOut error
+	for _, selectedProject := range selectedProjects {
+		err := runAction(action, selector, mruList, remotes, selectedProject)
+		if errors.Is(err, window.ErrWindowTimeout) {
+			timedOut = err
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return timedOut
+}
+
+// previewFlags returns the flags that make the preview command read the
+// settings and base dir of this invocation
+func previewFlags() []string {
+	baseDir, err := filepath.Abs(cfg.BaseDir)
+	if err != nil {
+		baseDir = cfg.BaseDir
+	}
+	flags := []string{"--base-dir", baseDir}
+	if cfgFile != "" {
+		flags = append(flags, "--config", cfgFile)
+	}
+	if selectorFile != "" {
+		flags = append(flags, "--selector-file", selectorFile)
+	}
+	if profile != "" {
+		flags = append(flags, "--profile", profile)
+	}
+	for _, setting := range settingOverrides {
+		flags = append(flags, "--set", setting)
+	}
+	return flags
+}
+
+// newProjectSelector sets up the selector with the annotators of the
+// project list
+func newProjectSelector(appConfig *core.Config, projects []string, mruList *mru.MRUList, remotes *remote.Registry, caches *annotationCaches) *core.Selector {
+	selector := core.NewSelector(appConfig)
+	selector.SetLaunchLog(cfg.LaunchLog)
+	if inContainer {
+		selector.UseContainer()
+	}
+	if useTUI {
+		selector.UseBuiltin()
+	}
+	selector.AddEditorAnnotator(positionAnnotator(openPositions()))
+	selector.AddEditorAnnotator(sessionAnnotator(appConfig.SessionNaming))
+	selector.AddEditorAnnotator(editorContextAnnotator)
+	if exe, err := os.Executable(); err == nil {
+		selector.EnablePreview(append([]string{exe, "preview"}, previewFlags()...)...)
+	}
+	paths := newPathStyler(appConfig, projects, remotes)
+	running := newRunningProjects(remotes, appConfig.SessionNaming)
+	showOpen := strings.Contains(appConfig.Format.ProjectTitle, ".Open")
+	selector.AddAnnotator(func(project string, data map[string]string) {
+		data["InMRU"] = flag(mruList.Contains(project))
+		data["Open"] = ""
+		if showOpen {
+			data["Open"] = flag(running.isOpen(project))
+		}
+		if _, _, ok := remotes.Lookup(project); ok {
+			data["Language"] = ""
+			data["Icon"] = core.LanguageIcon(appConfig.Format.IconSet, appConfig.Format.Icons, core.IconRemote)
+		} else {
+			data["Path"] = paths.Style(project)
+			data["Language"] = core.DetectLanguage(projectPath(project))
+			data["Icon"] = core.LanguageIcon(appConfig.Format.IconSet, appConfig.Format.Icons, data["Language"])
+		}
+		data["LastOpened"] = core.HumanizeSince(mruList.LastOpened(project))
+	})
+	annotateGit, cachedGit := gitAnnotators(caches.git)
+	selector.AddCachedLazyAnnotator([]string{"Branch", "Dirty"}, annotateGit, cachedGit)
+	annotateDescription, cachedDescription := descriptionAnnotators(caches.descriptions)
+	selector.AddCachedLazyAnnotator([]string{"Description"}, annotateDescription, cachedDescription)
+	selector.SetAnnotationBudget(cfg.AnnotationBudget)
+	selector.AddLazyAnnotator([]string{"Size"}, sizeAnnotator(dirConfig().SkipDirs))
+	return selector
+}
+
+// newPathStyler styles the paths of the local projects in the configured
+// path style
+func newPathStyler(appConfig *core.Config, projects []string, remotes *remote.Registry) *core.PathStyler {
+	var local []string
+	for _, project := range projects {
+		if _, _, ok := remotes.Lookup(project); !ok {
+			local = append(local, project)
+		}
 	}
+	return core.NewPathStyler(appConfig.Format.PathStyle, cfg.BaseDir, local)
+}
+
+// listProjects merges the MRU list, the projects found in the base dir and
+// the remote workspaces, in the configured order
+func listProjects(mruList *mru.MRUList, remotes *remote.Registry) ([]string, error) {
+	allProjects := scanProjects()
 
+	uniqueProjects := core.RemoveDuplicates(append(mruList.Items(), allProjects...))
+	for _, ws := range remotes.Workspaces() {
+		if clonedWorkspace(remotes, ws) {
+			continue // Listed as a local project
+		}
+		uniqueProjects = append(uniqueProjects, ws.Label())
+	}
+	if len(uniqueProjects) == 0 {
+		return nil, fmt.Errorf("no projects found in %s", cfg.BaseDir)
+	}
+	if err := sortProjects(cfg.Sort, uniqueProjects); err != nil {
+		return nil, fmt.Errorf("failed to sort projects: %w", err)
+	}
+	return uniqueProjects, nil
+}
+
+// scanProjects finds the projects of the base dir, taking them from the
+// index of the daemon when one runs with the same settings
+func scanProjects() []string {
+	if daemonIndex != nil {
+		projects, _ := daemonIndex.snapshot()
+		return projects
+	}
+	projects, err := daemonProjects()
+	if err == nil {
+		slog.Debug("projects listed by the daemon", "count", len(projects))
+		return projects
+	}
+	slog.Debug("scanning without the daemon", "reason", err)
+
+	start := time.Now()
+	projects = projectFinder().FindProjects(cfg.BaseDir)
+	took := time.Since(start)
+	recordStats(stats.Counters{Scans: 1, ScanTime: took})
+	slog.Debug("scanned projects", "dir", cfg.BaseDir, "count", len(projects), "took", took)
+	return projects
+}
+
+// runAction applies the action chosen in the selector to a project
+func runAction(action core.Action, selector *core.Selector, mruList *mru.MRUList, remotes *remote.Registry, project string) error {
+	ws, provider, isRemote := remotes.Lookup(project)
+	if cloner, ok := provider.(remote.Cloner); ok && action != core.ActionForget {
+		// Hosted repositories are opened like projects once cloned
+		local, err := cloneOnSelect(cloner, ws)
+		if err != nil {
+			return err
+		}
+		project, isRemote = local, false
+	}
+	if printPath && action != core.ActionForget {
+		return printProjectPath(mruList, remotes, project)
+	}
+	if inCodespace && action == core.ActionOpen {
+		action = core.ActionCodespace
+	}
+
+	switch action {
+	case core.ActionForget:
+		if isRemote {
+			return nil // Remote workspaces are not kept in the MRU list
+		}
+		return mruList.Remove(project)
+	case core.ActionTerminal:
+		if isRemote {
+			return launchRemote(selector, ws, provider)
+		}
+		return openTerminal(selector, mruList, project)
+	case core.ActionCodespace:
+		if isRemote {
+			return launchRemote(selector, ws, provider)
+		}
+		return openCodespace(selector, mruList, project)
+	case core.ActionNewWindow:
+		newWindow = true
+		return runAction(core.ActionOpen, selector, mruList, remotes, project)
+	default:
+		if profile, ok := action.EditorProfile(); ok {
+			if err := selector.UseEditor(profile); err != nil {
+				return err
+			}
+		}
+		if here {
+			return openHere(selector, mruList, remotes, project)
+		}
+		if isRemote {
+			return launchRemote(selector, ws, provider)
+		}
+		return openProject(selector, mruList, project)
+	}
+}
+
+// openProject launches or focuses the editor of a local project and
+// records the launch
+func openProject(selector *core.Selector, mruList *mru.MRUList, selectedProject string) error {
 	fullPath := filepath.Join(cfg.BaseDir, selectedProject)
 	if !isDirectory(fullPath) {
 		return fmt.Errorf("not a directory: %s", fullPath)
 	}
 
-	windowTitle := fmt.Sprintf("nvim ~ %s", filepath.Base(fullPath))
+	selector = selector.ForProject(fullPath)
+	windowTitle := editorWindowTitle(selector, fullPath)
+	find := editorWindow(selector, fullPath, windowTitle)
+	if newWindow {
+		windowTitle = newWindowInstance(selector, fullPath, windowTitle)
+		find = byTitle(windowTitle)
+	}
+	start, launched := trackLaunch(selector, func() error { return selector.Start(fullPath, windowTitle) })
+	// A launched editor is known by its process before its title
+	find = firstFound(byProcess(launched), find)
 
-	if err := launchOrFocusWindow(ctx, selector, fullPath, windowTitle); err != nil {
+	windowID, err := launchOrFocusWindow(start, find)
+	if launchFailed(err) {
 		return fmt.Errorf("failed to launch/focus window: %w", err)
 	}
+	runWindowCommands(windowID, selector.WindowCommands(selectedProject))
 
-	return mruList.Update(selectedProject)
+	if err := mruList.Update(selectedProject); err != nil {
+		return err
+	}
+	if err := recordLaunch(fullPath, windowTitle); err != nil {
+		return err
+	}
+	return err
 }
 
-// launchOrFocusWindow either focuses an existing window or launches a new one.
-func launchOrFocusWindow(ctx context.Context, selector *core.Selector, projectPath, windowTitle string) error {
-	windowManager := &core.WindowManager{}
-	windowID, _ := windowManager.FindWindow(windowTitle)
+// openTerminal launches or focuses a plain terminal in a local project
+func openTerminal(selector *core.Selector, mruList *mru.MRUList, selectedProject string) error {
+	fullPath := filepath.Join(cfg.BaseDir, selectedProject)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
 
+	windowTitle := fmt.Sprintf("terminal ~ %s", filepath.Base(fullPath))
+
+	start, launched := trackLaunch(selector, func() error { return selector.StartTerminal(fullPath, windowTitle) })
+	find := firstFound(byProcess(launched), byTitle(windowTitle))
+	_, err := launchOrFocusWindow(start, find)
+	if launchFailed(err) {
+		return fmt.Errorf("failed to launch/focus window: %w", err)
+	}
+
+	if err := mruList.Update(selectedProject); err != nil {
+		return err
+	}
+	return err
+}
+
+// openMRU opens the MRU list with backup rotation enabled
+func openMRU() *mru.MRUList {
+	mruList := mru.NewMRUList(cfg.MruFile, cfg.BaseDir)
+	mruList.SetBackups(newRotator())
+	return mruList
+}
+
+// openHistory opens the history log with backup rotation enabled
+func openHistory() *history.Log {
+	log := history.NewLog(cfg.HistoryFile)
+	log.SetBackups(newRotator())
+	return log
+}
+
+// openTracker opens the session tracker with backup rotation enabled
+func openTracker() *history.Tracker {
+	tracker := history.NewTracker(cfg.SessionsFile)
+	tracker.SetBackups(newRotator())
+	return tracker
+}
+
+// openPositions opens the position store with backup rotation enabled
+func openPositions() *position.Store {
+	store := position.NewStore(cfg.PositionsFile)
+	store.SetBackups(newRotator())
+	return store
+}
+
+// newRotator returns the backup rotator for state files
+func newRotator() *backup.Rotator {
+	return backup.NewRotator(cfg.BackupDir, cfg.BackupKeep, cfg.BackupMaxAge)
+}
+
+// launchRemote connects to a remote workspace in its own terminal window
+func launchRemote(selector *core.Selector, ws remote.Workspace, provider remote.Provider) error {
+	windowTitle := fmt.Sprintf("%s ~ %s", ws.Provider, ws.Name)
+	start, launched := trackLaunch(selector, func() error { return selector.StartRemote(windowTitle, provider.ConnectCommand(ws)) })
+	find := firstFound(byProcess(launched), byTitle(windowTitle))
+	if newWindow {
+		// Another terminal attached to the same remote session
+		find = byProcess(launched)
+	}
+
+	_, err := launchOrFocusWindow(start, find)
+	if launchFailed(err) {
+		return fmt.Errorf("failed to launch/focus window: %w", err)
+	}
+
+	if err := recordLaunch(ws.Label(), windowTitle); err != nil {
+		return err
+	}
+	return err
+}
+
+// newRemoteRegistry enables the configured remote providers, along with
+// SSH when projects on other hosts are configured and the forges listing
+// hosted repositories
+func newRemoteRegistry() (*remote.Registry, error) {
+	remotes, err := remote.NewRegistry(cfg.RemoteProviders)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.SSHProjects) > 0 {
+		ssh, err := remote.NewSSH(cfg.SSHProjects)
+		if err != nil {
+			return nil, err
+		}
+		remotes.Add(ssh)
+	}
+	if err := addForges(remotes); err != nil {
+		return nil, err
+	}
+	return remotes, nil
+}
+
+// recordLaunch appends a launch to the history log and, when enabled,
+// starts tracking its window so the session length can be recorded
+func recordLaunch(project, windowTitle string) error {
+	if err := openHistory().Append(project); err != nil {
+		return err
+	}
+	if !cfg.TrackSessions {
+		return nil
+	}
+	return openTracker().Start(project, windowTitle)
+}
+
+// windowAlive reports whether a window with the given title exists.
+// Backends that cannot find windows report an error so callers do not
+// mistake "unknown" for "closed".
+func windowAlive(title string) (bool, error) {
+	backend := windowBackend()
+	if !backend.Capabilities().Has(window.CanFind) {
+		return false, fmt.Errorf("window backend %s cannot find windows", backend.Name())
+	}
+
+	windowID, err := backend.FindWindow(title)
+	return windowID != 0, err
+}
+
+// windowBackend returns the window backend set by window_backend, or the
+// detected one. A backend that is unknown or not running is reported and
+// left out, so launching still works.
+func windowBackend() window.Backend {
+	backendOnce.Do(func() {
+		backend, err := window.Select(cfg.WindowBackend)
+		if err != nil {
+			slog.Warn("detecting the window backend instead", "err", err)
+			backend = window.Detect()
+		} else if !backend.Available() {
+			slog.Warn("window backend is not running, windows are not focused", "backend", backend.Name())
+			backend = window.None{}
+		}
+		slog.Debug("using window backend", "backend", backend.Name())
+		selectedBackend = backend
+	})
+	return selectedBackend
+}
+
+var (
+	backendOnce     sync.Once
+	selectedBackend window.Backend
+)
+
+// launchOrFocusWindow either focuses an existing window or launches a new one,
+// returning the ID of the window, or 0 when it was not seen. Steps the
+// detected window backend cannot perform are skipped. An error wrapping
+// window.ErrWindowTimeout means the launch worked but its window was not
+// seen, see launchFailed.
+func launchOrFocusWindow(start func() error, find windowFinder) (int64, error) {
+	backend := windowBackend()
+	caps := backend.Capabilities()
+
+	found := time.Now()
+	windowID, canFind := findWithin(backend, find, cfg.WindowFindTimeout)
+	slog.Debug("looked for an open window", "found", windowID != 0, "took", time.Since(found))
 	if windowID == 0 {
-		if err := selector.Start(projectPath, windowTitle); err != nil {
-			return err
+		launched := time.Now()
+		if err := start(); err != nil {
+			return 0, err
 		}
-		windowID, _ = waitForWindow(ctx, windowTitle)
-	} else {
-		if err := windowManager.FocusWindow(windowID); err != nil {
-			return err
+		slog.Debug("launched editor", "took", time.Since(launched))
+		if !canFind {
+			return 0, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.WindowWaitTimeout)
+		defer cancel()
+		var err error
+		if windowID, err = waitForWindow(ctx, backend, find); err != nil {
+			// Launching worked, the window may just be titled differently
+			return 0, fmt.Errorf("launched, but no window showed up within %s: %w", cfg.WindowWaitTimeout, err)
+		}
+		slog.Debug("window showed up", "took", time.Since(launched))
+	}
+	if caps.Has(window.CanFocus) {
+		if err := focusWindow(backend, windowID); err != nil {
+			// The window is there, only focusing it failed
+			slog.Warn("failed to focus window", "err", err)
 		}
 	}
 
-	return nil
+	return windowID, nil
+}
+
+// launchFailed reports whether an error of launchOrFocusWindow means
+// nothing was launched. A launch whose window was not seen is recorded
+// like any other before its error is returned.
+func launchFailed(err error) bool {
+	return err != nil && !errors.Is(err, window.ErrWindowTimeout)
+}
+
+// findWithin looks up a window, giving up after timeout so a window
+// manager that does not answer does not hold up launching. The lookup
+// given up on keeps running, so backends guard the state they keep.
+func findWithin(backend window.Backend, find windowFinder, timeout time.Duration) (int64, bool) {
+	type found struct {
+		windowID int64
+		canFind  bool
+	}
+	result := make(chan found, 1)
+	go func() {
+		windowID, canFind := find(backend)
+		result <- found{windowID, canFind}
+	}()
+
+	select {
+	case r := <-result:
+		return r.windowID, r.canFind
+	case <-time.After(timeout):
+		slog.Warn("window backend did not answer, launching", "backend", backend.Name(), "timeout", timeout)
+		return 0, false
+	}
+}
+
+// runWindowCommands runs window manager commands on a focused or launched
+// window. Failures are reported without failing the launch.
+func runWindowCommands(windowID int64, commands []string) {
+	if windowID == 0 || len(commands) == 0 {
+		return
+	}
+	commander, ok := windowBackend().(window.Commander)
+	if !ok {
+		slog.Warn("window backend cannot run window commands", "backend", windowBackend().Name())
+		return
+	}
+	for _, command := range commands {
+		if err := commander.RunCommand(windowID, command); err != nil {
+			slog.Warn("window command failed", "command", command, "err", err)
+		}
+	}
+}
+
+// focusWindow focuses a window, bringing it to the current workspace
+// first with bring_windows
+func focusWindow(backend window.Backend, windowID int64) error {
+	if cfg.BringWindows {
+		return window.Bring(backend, windowID)
+	}
+	return backend.FocusWindow(windowID)
+}
+
+// projectWindowTitle returns the title of the editor window of a project
+func projectWindowTitle(fullPath string) string {
+	return fmt.Sprintf("nvim ~ %s", filepath.Base(fullPath))
+}
+
+// editorWindowTitle returns the title of the editor window of a project,
+// named after the editor profile when one is in use to keep the windows of
+// different editors apart
+func editorWindowTitle(selector *core.Selector, fullPath string) string {
+	if profile := selector.EditorProfile(); profile != "" {
+		return fmt.Sprintf("%s ~ %s", profile, filepath.Base(fullPath))
+	}
+	return projectWindowTitle(fullPath)
+}
+
+// newWindowInstance picks the title of another window of a project, e.g.
+// "nvim ~ api #2", and gives it a tmux session of its own. The first number
+// with neither a window nor a session is used.
+func newWindowInstance(selector *core.Selector, fullPath, windowTitle string) string {
+	backend := windowBackend()
+	sessions := tmuxSessions()
+	session := newSessionName(sessions, selector.SessionNaming(), fullPath)
+
+	for n := 2; ; n++ {
+		title := fmt.Sprintf("%s #%d", windowTitle, n)
+		suffix := fmt.Sprintf("_%d", n)
+		if _, taken := sessions[session+suffix]; taken {
+			continue
+		}
+		if windowID, _ := byTitle(title)(backend); windowID != 0 {
+			continue
+		}
+		selector.SetSessionSuffix(suffix)
+		return title
+	}
+}
+
+// projectPath resolves a project relative to the base dir
+func projectPath(project string) string {
+	if filepath.IsAbs(project) {
+		return project
+	}
+	return filepath.Join(cfg.BaseDir, project)
+}
+
+// resolveLocalProject finds the local project a name refers to, the way
+// code open does
+func resolveLocalProject(name string) (string, error) {
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return "", err
+	}
+	projects, err := listProjects(openMRU(), remotes)
+	if err != nil {
+		return "", err
+	}
+
+	project, err := core.ResolveProject(name, projects)
+	if err != nil {
+		return "", err
+	}
+	if _, _, ok := remotes.Lookup(project); ok {
+		return "", fmt.Errorf("%s is a remote workspace", project)
+	}
+	return project, nil
+}
+
+// expandHome resolves a leading ~/ of a configured path to the home dir
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
 }
 
 // isDirectory checks if the given path is a directory.
@@ -175,21 +878,102 @@ func isDirectory(path string) bool {
 	return err == nil && info.IsDir()
 }
 
-// waitForWindow waits for a window with the given title to appear.
-func waitForWindow(ctx context.Context, title string) (int64, error) {
-	backoff := initialBackoff
-	windowManager := &core.WindowManager{}
+// windowFinder looks up the window of a project, returning 0 when there is
+// none and false when the backend cannot look it up
+type windowFinder func(backend window.Backend) (int64, bool)
+
+// firstFound tries finders in order
+func firstFound(finders ...windowFinder) windowFinder {
+	return func(backend window.Backend) (int64, bool) {
+		canFind := false
+		for _, find := range finders {
+			windowID, ok := find(backend)
+			if windowID != 0 {
+				return windowID, true
+			}
+			canFind = canFind || ok
+		}
+		return 0, canFind
+	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return 0, fmt.Errorf("timeout waiting for window: %s", title)
-		default:
-			if windowID, _ := windowManager.FindWindow(title); windowID != 0 {
-				return windowID, nil
+// editorWindow finds the editor window of a local project: by title or
+// through its tmux session, or by app for GUI editors
+func editorWindow(selector *core.Selector, fullPath, windowTitle string) windowFinder {
+	if app := selector.EditorApp(); app != "" {
+		// GUI editors title their windows themselves
+		return byApp(app, filepath.Base(fullPath))
+	}
+	return firstFound(byTitle(windowTitle), bySessionClient(selector.SessionNaming(), fullPath))
+}
+
+// byTitle finds the window with the given title
+func byTitle(title string) windowFinder {
+	return func(backend window.Backend) (int64, bool) {
+		if !backend.Capabilities().Has(window.CanFind) {
+			return 0, false
+		}
+		windowID, _ := backend.FindWindow(title)
+		return windowID, true
+	}
+}
+
+// trackLaunch wraps start, returning with it the process it launched, or 0
+// before it ran. A lookup given up on by findWithin may still ask for the
+// process while start runs, hence the lock.
+func trackLaunch(selector *core.Selector, start func() error) (func() error, func() int) {
+	var mu sync.Mutex
+	pid := 0
+	track := func() error {
+		err := start()
+		mu.Lock()
+		defer mu.Unlock()
+		pid = selector.LaunchedPID()
+		return err
+	}
+	launched := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return pid
+	}
+	return track, launched
+}
+
+// byProcess finds the window of a process started by the launcher, or of
+// one of its children, e.g. a terminal started through direnv. It finds
+// nothing before the launch or when the process handed over to a running
+// instance.
+func byProcess(pid func() int) windowFinder {
+	return func(backend window.Backend) (int64, bool) {
+		finder, ok := backend.(window.PIDFinder)
+		if !ok || pid() == 0 {
+			return 0, false
+		}
+		for _, p := range append([]int{pid()}, descendantPIDs(pid())...) {
+			if windowID, _ := finder.FindWindowByPID(p); windowID != 0 {
+				return windowID, true
 			}
-			time.Sleep(backoff)
-			backoff *= backoffFactor
 		}
+		return 0, true
 	}
 }
+
+// byApp finds a window of a GUI application whose title names the project
+func byApp(app, project string) windowFinder {
+	return func(backend window.Backend) (int64, bool) {
+		finder, ok := backend.(window.AppFinder)
+		if !ok {
+			return 0, false
+		}
+		windowID, _ := finder.FindAppWindow(app, project)
+		return windowID, true
+	}
+}
+
+// waitForWindow waits for the window found by find to appear.
+func waitForWindow(ctx context.Context, backend window.Backend, find windowFinder) (int64, error) {
+	return window.WaitForWindow(ctx, backend, func() int64 {
+		windowID, _ := find(backend)
+		return windowID
+	})
+}
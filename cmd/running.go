@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/marianozunino/code/v2/internal/window"
+)
+
+// runningProjects tells which projects already have an editor window or
+// tmux session. Windows and sessions are listed once, on first use.
+type runningProjects struct {
+	remotes  *remote.Registry
+	naming   string // tmux session naming strategy
+	once     sync.Once
+	backend  window.Backend
+	titles   map[string]bool // nil when the backend cannot list windows
+	sessions map[string]string
+}
+
+// newRunningProjects creates a lookup for open projects
+func newRunningProjects(remotes *remote.Registry, naming string) *runningProjects {
+	return &runningProjects{remotes: remotes, naming: naming}
+}
+
+// load lists the open windows and tmux sessions
+func (r *runningProjects) load() {
+	r.backend = windowBackend()
+	if lister, ok := r.backend.(window.Lister); ok {
+		if titles, err := lister.WindowTitles(); err == nil {
+			r.titles = make(map[string]bool, len(titles))
+			for _, title := range titles {
+				r.titles[title] = true
+			}
+		}
+	}
+
+	r.sessions = tmuxSessions()
+}
+
+// isOpen reports whether a project has an editor window or tmux session
+func (r *runningProjects) isOpen(project string) bool {
+	r.once.Do(r.load)
+
+	if ws, _, ok := r.remotes.Lookup(project); ok {
+		return r.hasWindow(fmt.Sprintf("%s ~ %s", ws.Provider, ws.Name))
+	}
+
+	fullPath := projectPath(project)
+	if _, ok := projectSession(r.sessions, r.naming, fullPath); ok {
+		return true
+	}
+	return r.hasWindow(projectWindowTitle(fullPath))
+}
+
+// hasWindow reports whether a window with the given title exists, finding
+// it directly when the backend cannot list windows
+func (r *runningProjects) hasWindow(title string) bool {
+	if r.titles != nil {
+		return r.titles[title]
+	}
+	if !r.backend.Capabilities().Has(window.CanFind) {
+		return false
+	}
+	windowID, _ := r.backend.FindWindow(title)
+	return windowID != 0
+}
+
+// flag renders a boolean for templates: "true" or empty, so it can be
+// tested with {{if}}
+func flag(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
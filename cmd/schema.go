@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/schema"
+	"github.com/marianozunino/code/v2/internal/window"
+	"github.com/spf13/cobra"
+)
+
+var schemaSelector bool
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema of the configuration files",
+	Long: `Schema prints a JSON Schema of ~/.code.yaml, or of the selector file with
+--selector, for editors to complete and check the files with.
+
+With yaml-language-server, save it and point the file at it on its first
+line:
+
+  # yaml-language-server: $schema=/home/me/.config/code/schema.json`,
+	Example: `  code config schema > ~/.config/code/schema.json
+  code config schema --selector > ~/.config/code/selector.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: printConfigSchema,
+}
+
+func init() {
+	configSchemaCmd.Flags().BoolVar(&schemaSelector, "selector", false, "describe the selector file instead of ~/.code.yaml")
+
+	configCmd.AddCommand(configSchemaCmd)
+}
+
+// printConfigSchema writes the schema of the chosen file to stdout
+func printConfigSchema(cmd *cobra.Command, args []string) error {
+	s := mainConfigSchema()
+	if schemaSelector {
+		s = selectorConfigSchema()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// mainConfigSchema describes ~/.code.yaml, whose profiles take the same
+// settings as the top level
+func mainConfigSchema() *schema.Schema {
+	g := &schema.Generator{
+		Tag: "mapstructure",
+		Enums: map[string][]string{
+			"sort":           {core.SortMRU, core.SortFrecency, core.SortAlpha, core.SortRecentlyModified},
+			"window_backend": window.Names(),
+		},
+	}
+
+	s := g.Generate("code config", Config{})
+	s.Properties["profiles"] = &schema.Schema{Type: "object", AdditionalProperties: g.For(Config{})}
+	return s
+}
+
+// selectorConfigSchema describes the selector file, where the selector,
+// terminal and editors can also be given as preset names
+func selectorConfigSchema() *schema.Schema {
+	g := &schema.Generator{
+		Tag: "yaml",
+		Enums: map[string][]string{
+			"selector":                         core.SelectorPresetNames(),
+			"selectors.*":                      core.SelectorPresetNames(),
+			"terminal":                         core.TerminalPresetNames(),
+			"editor":                           core.EditorPresetNames(),
+			"editors.*":                        core.EditorPresetNames(),
+			"format.icon_set":                  {core.IconSetEmoji, core.IconSetNerd, core.IconSetASCII},
+			"project_env":                      {core.ProjectEnvAuto, core.ProjectEnvDirenv, core.ProjectEnvNix},
+			"session_naming":                   {core.SessionNamingBasename, core.SessionNamingParent, core.SessionNamingHash},
+			"editor.layout.windows.*.split":    {core.SplitHorizontal, core.SplitVertical},
+			"editors.*.layout.windows.*.split": {core.SplitHorizontal, core.SplitVertical},
+		},
+	}
+	return g.Generate("code selector file", core.Config{})
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var selectStdin bool
+
+var selectCmd = &cobra.Command{
+	Use:   "select",
+	Short: "Pick projects with the selector and print them",
+	Long: `Select shows the configured selector and prints the chosen projects, one
+per line, without launching anything.
+
+With --stdin the candidates are read from standard input instead of the
+project list and printed back as given, so other scripts can reuse the
+selector, format and extract configuration.`,
+	Example: `  code select
+  ls ~/notes | code select --stdin`,
+	Args: cobra.NoArgs,
+	RunE: selectProjects,
+}
+
+func init() {
+	selectCmd.Flags().BoolVar(&selectStdin, "stdin", false, "read the candidates from standard input")
+	selectCmd.Flags().BoolVar(&useTUI, "tui", false, "select with the built-in terminal finder")
+	rootCmd.AddCommand(selectCmd)
+}
+
+// selectProjects runs the selector and prints the selection
+func selectProjects(cmd *cobra.Command, args []string) error {
+	if err := core.ValidateSortMode(cfg.Sort); err != nil {
+		return err
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+
+	var candidates []string
+	if selectStdin {
+		candidates, err = readCandidates()
+	} else {
+		candidates, err = listProjects(mruList, remotes)
+	}
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no candidates on standard input")
+	}
+
+	appConfig, err := loadSelectorConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	caches := openAnnotationCaches()
+	defer caches.Save()
+
+	selector := newProjectSelector(appConfig, candidates, mruList, remotes, caches)
+	_, selected, err := selector.Select(candidates)
+	if err != nil {
+		return fmt.Errorf("selection failed: %w", err)
+	}
+
+	for _, project := range selected {
+		if _, _, ok := remotes.Lookup(project); !ok && !selectStdin {
+			project = projectPath(project)
+		}
+		fmt.Println(project)
+	}
+	return nil
+}
+
+// readCandidates reads the non-empty lines of standard input
+func readCandidates() ([]string, error) {
+	var candidates []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			candidates = append(candidates, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read candidates: %w", err)
+	}
+	return core.RemoveDuplicates(candidates), nil
+}
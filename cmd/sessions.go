@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var watchInterval time.Duration
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Show the editor sessions currently being tracked",
+	Long: `Show the editor sessions currently being tracked.
+
+Session tracking is enabled with track_sessions: true. A session ends when
+its window disappears; this is noticed on the next invocation, or sooner
+when "code sessions watch" is running.`,
+	Args: cobra.NoArgs,
+	RunE: listSessions,
+}
+
+var sessionsWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Periodically record sessions whose window has closed",
+	Args:  cobra.NoArgs,
+	RunE:  watchSessions,
+}
+
+func init() {
+	sessionsWatchCmd.Flags().DurationVar(&watchInterval, "interval", time.Minute, "how often to check tracked windows")
+	sessionsCmd.AddCommand(sessionsWatchCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
+
+// listSessions prints the tracked sessions and how long they have been open
+func listSessions(cmd *cobra.Command, args []string) error {
+	sessions, err := openTracker().Sessions()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		fmt.Printf("%s  %8s  %s\n", s.Started.Format("2006-01-02 15:04"), formatDuration(time.Since(s.Started)), displayPath(s.Project))
+	}
+	return nil
+}
+
+// watchSessions reaps closed sessions until interrupted
+func watchSessions(cmd *cobra.Command, args []string) error {
+	config := newConfigWatcher()
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		config.reloadIfChanged()
+		tracker := openTracker()
+		if err := tracker.Reap(windowAlive, openHistory()); err != nil {
+			return err
+		}
+
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
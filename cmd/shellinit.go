@@ -0,0 +1,119 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/mru"
+	"github.com/marianozunino/code/v2/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// functionName matches the names shell-init accepts for its function
+var functionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// shellInitScripts are the cd functions per shell, given the function name
+// and the quoted command
+var shellInitScripts = map[string]string{
+	"bash": posixInitScript,
+	"zsh":  posixInitScript,
+	"fish": `function %[1]s --description 'cd into a project picked with code'
+    set -l dir (%[2]s --print-path $argv)
+    or return
+    test -n "$dir"; and cd -- $dir
+end
+`,
+}
+
+// posixInitScript is the cd function of bash and zsh
+const posixInitScript = `%[1]s() {
+    local dir
+    dir="$(%[2]s --print-path "$@")" || return
+    [ -n "$dir" ] && cd -- "$dir"
+}
+`
+
+var shellInitName string
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <bash|zsh|fish>",
+	Short: "Print a shell function that cds into a selected project",
+	Long: `Print a shell function that shows the selector in --print-path mode and
+changes into the chosen project, so the launcher also drives plain shell
+navigation. Arguments of the function are passed on, e.g. -f api to pick
+without the selector when only one project matches.
+
+Evaluate it from the shell's startup file:
+
+  eval "$(code shell-init bash)"          # ~/.bashrc
+  eval "$(code shell-init zsh)"           # ~/.zshrc
+  code shell-init fish | source           # ~/.config/fish/config.fish`,
+	Example:   `  code shell-init zsh --name p`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE:      printShellInit,
+}
+
+func init() {
+	shellInitCmd.Flags().StringVar(&shellInitName, "name", "cdp", "name of the shell function")
+	rootCmd.AddCommand(shellInitCmd)
+}
+
+// printShellInit prints the cd function for a shell
+func printShellInit(cmd *cobra.Command, args []string) error {
+	script, ok := shellInitScripts[args[0]]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", args[0])
+	}
+	if !functionName.MatchString(shellInitName) {
+		return fmt.Errorf("invalid function name %q", shellInitName)
+	}
+
+	// Call this executable by path, "code" often names another editor
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fmt.Printf(script, shellInitName, core.ShellJoin([]string{exe}))
+	return nil
+}
+
+// printProjectPath prints the directory of a selected project instead of
+// launching it, counting it as opened in the MRU list
+func printProjectPath(mruList *mru.MRUList, remotes *remote.Registry, project string) error {
+	if _, _, ok := remotes.Lookup(project); ok {
+		return fmt.Errorf("%s is a remote workspace without a local path", project)
+	}
+	fullPath := projectPath(project)
+	if !isDirectory(fullPath) {
+		return fmt.Errorf("not a directory: %s", fullPath)
+	}
+	if err := mruList.Update(project); err != nil {
+		return err
+	}
+	fmt.Println(fullPath)
+	return nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/history"
+)
+
+// sortProjects orders the merged project list by the configured sort
+// mode. The MRU mode keeps the MRU-then-scan order as is.
+func sortProjects(mode string, projects []string) error {
+	switch mode {
+	case core.SortAlpha:
+		core.SortAlphabetically(projects)
+	case core.SortFrecency:
+		records, err := openHistory().Records()
+		if err != nil {
+			return err
+		}
+		scores := history.Frecency(records, time.Now())
+		core.SortByScore(projects, func(project string) float64 {
+			if score, ok := scores[project]; ok {
+				return score // Remote workspaces are logged by label
+			}
+			return scores[projectPath(project)]
+		})
+	case core.SortRecentlyModified:
+		core.SortByScore(projects, func(project string) float64 {
+			modified := core.ModifiedTime(projectPath(project))
+			if modified.IsZero() {
+				return 0
+			}
+			return float64(modified.UnixNano())
+		})
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreFrom string
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Inspect and restore backups of state files",
+}
+
+var stateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available state backups, newest first",
+	Args:  cobra.NoArgs,
+	RunE:  listBackups,
+}
+
+var stateRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a state file from a backup",
+	Args:  cobra.NoArgs,
+	RunE:  restoreBackup,
+}
+
+func init() {
+	stateRestoreCmd.Flags().StringVar(&restoreFrom, "from", "", "backup file name or path to restore")
+	stateRestoreCmd.MarkFlagRequired("from")
+
+	stateCmd.AddCommand(stateListCmd, stateRestoreCmd)
+	rootCmd.AddCommand(stateCmd)
+}
+
+// stateFiles returns the state files managed by backup rotation
+func stateFiles() []string {
+	return []string{cfg.MruFile, cfg.HistoryFile, cfg.SessionsFile, cfg.PositionsFile, cfg.StatsFile}
+}
+
+// listBackups prints every backup known to the rotator
+func listBackups(cmd *cobra.Command, args []string) error {
+	backups, err := newRotator().List("")
+	if err != nil {
+		return err
+	}
+
+	for _, b := range backups {
+		fmt.Printf("%s\t%s\n", b.Name, b.Created.Format(time.DateTime))
+	}
+	return nil
+}
+
+// restoreBackup copies a backup over the state file it was taken from
+func restoreBackup(cmd *cobra.Command, args []string) error {
+	rotator := newRotator()
+
+	b, err := rotator.Resolve(restoreFrom)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range stateFiles() {
+		if !b.Matches(target) {
+			continue
+		}
+		if err := rotator.Restore(b, target); err != nil {
+			return fmt.Errorf("restore failed: %w", err)
+		}
+		fmt.Printf("Restored %s from %s\n", target, b.Name)
+		return nil
+	}
+
+	return fmt.Errorf("backup %s does not belong to a known state file", b.Name)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/marianozunino/code/v2/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+// Days and weeks the text report of stats goes back
+const (
+	statsDays  = 14
+	statsWeeks = 8
+)
+
+var (
+	statsSince string
+	statsLimit int
+	statsJSON  bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show usage statistics",
+	Long: `Show how often projects were opened, overall and per day and week, from
+the history log, along with the average time of project scans and the hit
+rate of the git status and description caches.
+
+The text report lists the 14 latest days and 8 latest weeks with launches;
+--json reports all of them for dashboards.`,
+	Example: `  code stats
+  code stats --since 720h -n 5
+  code stats --json`,
+	Args: cobra.NoArgs,
+	RunE: showStats,
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "only count launches after this date or duration ago")
+	statsCmd.Flags().IntVarP(&statsLimit, "limit", "n", 10, "show at most this many projects, 0 for all")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the statistics as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
+
+// statsReport is the statistics as printed by stats --json
+type statsReport struct {
+	Opens         int            `json:"opens"`
+	Projects      []projectOpens `json:"projects"`
+	Days          []periodOpens  `json:"days"`
+	Weeks         []periodOpens  `json:"weeks"`
+	Scans         int            `json:"scans"`
+	AverageScanMs float64        `json:"average_scan_ms"`
+	CacheHits     int            `json:"cache_hits"`
+	CacheMisses   int            `json:"cache_misses"`
+	CacheHitRate  float64        `json:"cache_hit_rate"`
+}
+
+// projectOpens counts the launches of a project
+type projectOpens struct {
+	Project string `json:"project"`
+	Opens   int    `json:"opens"`
+}
+
+// periodOpens counts the launches of a day (2006-01-02) or an ISO week
+// (2006-W01)
+type periodOpens struct {
+	Period string `json:"period"`
+	Opens  int    `json:"opens"`
+}
+
+// showStats prints the usage statistics
+func showStats(cmd *cobra.Command, args []string) error {
+	since, err := parseTimeBound(statsSince)
+	if err != nil {
+		return err
+	}
+
+	records, err := openHistory().Records()
+	if err != nil {
+		return err
+	}
+	records = history.Between(records, since, time.Time{})
+
+	counters, err := stats.Load(cfg.StatsFile)
+	if err != nil {
+		return err
+	}
+
+	report := newStatsReport(records, counters)
+	if statsLimit > 0 && len(report.Projects) > statsLimit {
+		report.Projects = report.Projects[:statsLimit]
+	}
+
+	if statsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	printStatsReport(report)
+	return nil
+}
+
+// newStatsReport counts the launches of the records, most used projects
+// first and periods in chronological order
+func newStatsReport(records []history.Record, counters stats.Counters) statsReport {
+	perProject := map[string]int{}
+	perDay := map[string]int{}
+	perWeek := map[string]int{}
+	for _, r := range records {
+		perProject[displayPath(r.Project)]++
+		perDay[r.Time.Local().Format(dateLayout)]++
+		year, week := r.Time.Local().ISOWeek()
+		perWeek[fmt.Sprintf("%d-W%02d", year, week)]++
+	}
+
+	report := statsReport{
+		Opens:         len(records),
+		Projects:      []projectOpens{},
+		Days:          periods(perDay),
+		Weeks:         periods(perWeek),
+		Scans:         counters.Scans,
+		AverageScanMs: float64(counters.AverageScan()) / float64(time.Millisecond),
+		CacheHits:     counters.CacheHits,
+		CacheMisses:   counters.CacheMisses,
+		CacheHitRate:  counters.HitRate(),
+	}
+	for project, opens := range perProject {
+		report.Projects = append(report.Projects, projectOpens{Project: project, Opens: opens})
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		a, b := report.Projects[i], report.Projects[j]
+		if a.Opens != b.Opens {
+			return a.Opens > b.Opens
+		}
+		return a.Project < b.Project
+	})
+	return report
+}
+
+// periods sorts counts keyed by period, which sort chronologically as text
+func periods(counts map[string]int) []periodOpens {
+	result := make([]periodOpens, 0, len(counts))
+	for period, opens := range counts {
+		result = append(result, periodOpens{Period: period, Opens: opens})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Period < result[j].Period })
+	return result
+}
+
+// printStatsReport prints the report as text, with the recent days and
+// weeks only
+func printStatsReport(report statsReport) {
+	fmt.Printf("Opens: %d\n", report.Opens)
+
+	if len(report.Projects) > 0 {
+		fmt.Println("\nMost used:")
+		for _, p := range report.Projects {
+			fmt.Printf("  %5d  %s\n", p.Opens, p.Project)
+		}
+	}
+
+	if days := recentPeriods(report.Days, statsDays); len(days) > 0 {
+		fmt.Println("\nPer day:")
+		for _, d := range days {
+			fmt.Printf("  %-10s  %5d\n", d.Period, d.Opens)
+		}
+	}
+
+	if weeks := recentPeriods(report.Weeks, statsWeeks); len(weeks) > 0 {
+		fmt.Println("\nPer week:")
+		for _, w := range weeks {
+			fmt.Printf("  %-10s  %5d\n", w.Period, w.Opens)
+		}
+	}
+
+	fmt.Println()
+	if report.Scans > 0 {
+		fmt.Printf("Average scan time: %.1fms over %d scans\n", report.AverageScanMs, report.Scans)
+	} else {
+		fmt.Println("Average scan time: no scans recorded")
+	}
+	if lookups := report.CacheHits + report.CacheMisses; lookups > 0 {
+		fmt.Printf("Cache hit rate: %.0f%% of %d lookups\n", report.CacheHitRate*100, lookups)
+	} else {
+		fmt.Println("Cache hit rate: no lookups recorded")
+	}
+}
+
+// recentPeriods returns the last n periods with launches
+func recentPeriods(all []periodOpens, n int) []periodOpens {
+	if len(all) > n {
+		return all[len(all)-n:]
+	}
+	return all
+}
+
+// recordStats adds to the usage counters. Failures are ignored like those
+// of cache writes, statistics are not worth failing a launch over.
+func recordStats(delta stats.Counters) {
+	if delta == (stats.Counters{}) || cfg.StatsFile == "" {
+		return
+	}
+	stats.Add(cfg.StatsFile, delta, newRotator())
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suggestWindow  time.Duration
+	suggestMinDays int
+	suggestStale   time.Duration
+	suggestYes     bool
+	suggestNotify  bool
+)
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest pinning daily projects and unpinning stale ones",
+	Long: `Analyze the history log and suggest pinning projects opened nearly every
+day and unpinning pinned projects that have not been opened for a while.
+
+Each suggestion is applied only after confirmation. With --notify the
+suggestions are sent as a desktop notification instead, which makes the
+command suitable for a periodic timer.`,
+	Args: cobra.NoArgs,
+	RunE: suggestPins,
+}
+
+func init() {
+	suggestCmd.Flags().DurationVar(&suggestWindow, "window", 7*24*time.Hour, "period analyzed for frequently opened projects")
+	suggestCmd.Flags().IntVar(&suggestMinDays, "min-days", 5, "days within the window a project must be opened on to suggest pinning")
+	suggestCmd.Flags().DurationVar(&suggestStale, "stale", 30*24*time.Hour, "suggest unpinning projects not opened for this long")
+	suggestCmd.Flags().BoolVarP(&suggestYes, "yes", "y", false, "apply all suggestions without asking")
+	suggestCmd.Flags().BoolVar(&suggestNotify, "notify", false, "send suggestions as a desktop notification without applying them")
+	rootCmd.AddCommand(suggestCmd)
+}
+
+// suggestion is a proposed change to the pinned state of a project
+type suggestion struct {
+	project string
+	pin     bool
+	reason  string
+}
+
+func (s suggestion) String() string {
+	action := "Unpin"
+	if s.pin {
+		action = "Pin"
+	}
+	return fmt.Sprintf("%s %s (%s)", action, displayPath(s.project), s.reason)
+}
+
+// suggestPins computes and applies pin suggestions
+func suggestPins(cmd *cobra.Command, args []string) error {
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	records, err := openHistory().Records()
+	if err != nil {
+		return err
+	}
+
+	var suggestions []suggestion
+
+	recent := history.Between(records, time.Now().Add(-suggestWindow), time.Time{})
+	for project, days := range history.ActiveDays(recent) {
+		if days < suggestMinDays || mruList.IsPinned(project) || !isDirectory(project) {
+			continue
+		}
+		suggestions = append(suggestions, suggestion{
+			project: project,
+			pin:     true,
+			reason:  fmt.Sprintf("opened on %d of the last %d days", days, int(suggestWindow.Hours()/24)),
+		})
+	}
+
+	lastOpened := history.LastOpened(records)
+	for _, project := range mruList.Pinned() {
+		last := mruList.LastOpened(project)
+		if t := lastOpened[projectPath(project)]; t.After(last) {
+			last = t
+		}
+		if !last.IsZero() && time.Since(last) < suggestStale {
+			continue
+		}
+		reason := "never opened"
+		if !last.IsZero() {
+			reason = "last opened " + last.Format(dateLayout)
+		}
+		suggestions = append(suggestions, suggestion{project: projectPath(project), reason: reason})
+	}
+
+	if len(suggestions) == 0 {
+		return nil
+	}
+
+	if suggestNotify {
+		lines := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			lines[i] = s.String()
+		}
+		return exec.Command("notify-send", "code: pin suggestions", strings.Join(lines, "\n")).Run()
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, s := range suggestions {
+		if !suggestYes && !confirm(reader, s.String()+"?") {
+			continue
+		}
+		apply := mruList.Unpin
+		if s.pin {
+			apply = mruList.Pin
+		}
+		if err := apply(s.project); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on stdout, defaulting to no
+func confirm(reader *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
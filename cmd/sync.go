@@ -0,0 +1,225 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/gitstatus"
+	"github.com/spf13/cobra"
+)
+
+// defaultSyncJobs is how many repositories are fetched at once by default.
+// Fetches wait on the network rather than the CPU.
+const defaultSyncJobs = 8
+
+var (
+	syncPull    bool
+	syncJobs    int
+	syncTimeout time.Duration
+	syncJSON    bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [filter]",
+	Short: "Fetch every project repository and report how it compares to upstream",
+	Long: `Fetch the remotes of every git repository of the base dir, --jobs at a
+time, then print how far the current branch of each is ahead of and behind
+its upstream and whether its working tree is dirty.
+
+With --pull, repositories with a clean working tree on their default
+branch are fast-forwarded to their upstream; others are only fetched.
+Git never prompts for credentials, so repositories needing them fail.
+A fuzzy filter limits the projects synced.`,
+	Example: `  code sync
+  code sync --pull
+  code sync -j 16 work/ --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: syncProjects,
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncPull, "pull", false, "fast-forward clean repositories on their default branch")
+	syncCmd.Flags().IntVarP(&syncJobs, "jobs", "j", defaultSyncJobs, "repositories synced at once")
+	syncCmd.Flags().DurationVar(&syncTimeout, "timeout", time.Minute, "time each repository may take")
+	syncCmd.Flags().BoolVar(&syncJSON, "json", false, "print the report as JSON")
+	rootCmd.AddCommand(syncCmd)
+}
+
+// syncResult is the state of a repository after syncing, as printed by
+// sync --json
+type syncResult struct {
+	Project  string `json:"project"`
+	Branch   string `json:"branch"`
+	Upstream bool   `json:"upstream"` // Whether the branch tracks a remote branch
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	Dirty    bool   `json:"dirty"`
+	Pulled   int    `json:"pulled"` // Commits fast-forwarded with --pull
+	Error    string `json:"error,omitempty"`
+}
+
+// syncProjects syncs the repositories of the base dir and prints the report
+func syncProjects(cmd *cobra.Command, args []string) error {
+	if syncJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+
+	var projects []string
+	for _, project := range scanProjects() {
+		if isGitRepo(projectPath(project)) {
+			projects = append(projects, project)
+		}
+	}
+	if len(args) > 0 {
+		if projects = core.FilterProjects(args[0], projects); len(projects) == 0 {
+			return fmt.Errorf("%w: %s", core.ErrNoMatch, args[0])
+		}
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("no git repositories found in %s", cfg.BaseDir)
+	}
+
+	results := make([]chan syncResult, len(projects))
+	sem := make(chan struct{}, syncJobs)
+	for i, project := range projects {
+		results[i] = make(chan syncResult, 1)
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] <- syncRepo(project)
+		}()
+	}
+
+	report := make([]syncResult, len(projects))
+	var failed []string
+	for i := range projects {
+		report[i] = <-results[i]
+		if report[i].Error != "" {
+			failed = append(failed, report[i].Project)
+		}
+	}
+
+	if syncJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printSyncReport(report)
+	}
+
+	if len(failed) > 0 {
+		// Errors of the repositories are not about usage
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed in %d of %d projects: %s", len(failed), len(projects), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// syncRepo fetches a repository and, with --pull, fast-forwards its
+// default branch when checked out with a clean working tree
+func syncRepo(project string) syncResult {
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	dir := projectPath(project)
+	result := syncResult{Project: project}
+	fail := func(err error) syncResult {
+		result.Error = err.Error()
+		return result
+	}
+
+	status, err := gitstatus.Read(ctx, dir)
+	if err != nil {
+		return fail(err)
+	}
+	result.Branch, result.Dirty = status.Branch, status.Dirty
+
+	if err := gitstatus.Fetch(ctx, dir); err != nil {
+		return fail(err)
+	}
+	result.Ahead, result.Behind, err = gitstatus.AheadBehind(ctx, dir)
+	if errors.Is(err, gitstatus.ErrNoUpstream) {
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+	result.Upstream = true
+
+	if !syncPull || result.Dirty || result.Behind == 0 || result.Ahead > 0 {
+		return result
+	}
+	if defaultBranch, err := gitstatus.DefaultBranch(ctx, dir); err != nil || defaultBranch != result.Branch {
+		return result
+	}
+	if err := gitstatus.FastForward(ctx, dir); err != nil {
+		return fail(err)
+	}
+	result.Pulled, result.Behind = result.Behind, 0
+	return result
+}
+
+// printSyncReport prints a line per repository, e.g.
+// "api  main  ↑1 ↓0  dirty  pulled 3"
+func printSyncReport(report []syncResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range report {
+		state := "clean"
+		if r.Dirty {
+			state = "dirty"
+		}
+		remote := "-"
+		if r.Upstream {
+			remote = "↑" + strconv.Itoa(r.Ahead) + " ↓" + strconv.Itoa(r.Behind)
+		} else if r.Error == "" {
+			remote = "no upstream"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s", r.Project, orDash(r.Branch), remote, state)
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(w, "\t%s", r.Error)
+		case r.Pulled > 0:
+			fmt.Fprintf(w, "\tpulled %d", r.Pulled)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// isGitRepo reports whether dir is the top of a git repository or worktree
+func isGitRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/window"
+)
+
+// maxClientAncestors bounds the walk from a tmux client up to the terminal
+// owning its window
+const maxClientAncestors = 8
+
+// tmuxLines runs a tmux command and returns its output lines. Errors, e.g.
+// when no tmux server is running, yield no lines.
+func tmuxLines(args ...string) []string {
+	output, err := exec.Command("tmux", args...).Output()
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// tmuxSessions returns the running tmux sessions and the directories they
+// were started in
+func tmuxSessions() map[string]string {
+	sessions := make(map[string]string)
+	for _, line := range tmuxLines("list-sessions", "-F", "#{session_name}\t#{session_path}") {
+		name, path, _ := strings.Cut(line, "\t")
+		sessions[name] = filepath.Clean(path)
+	}
+	return sessions
+}
+
+// sessionCandidates returns the session names a project may use. Sessions
+// named after the directory as is, from before names were sanitized, are
+// still recognized.
+func sessionCandidates(naming, dir string) []string {
+	names := core.SessionNames(naming, dir)
+	if naming == "" || naming == core.SessionNamingBasename {
+		names = append([]string{filepath.Base(dir)}, names...)
+	}
+	return names
+}
+
+// projectSession returns the running tmux session of a project among
+// sessions. A session named like the project but started in another
+// directory belongs to another project.
+func projectSession(sessions map[string]string, naming, dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for _, session := range sessionCandidates(naming, dir) {
+		if path, ok := sessions[session]; ok && path == dir {
+			return session, true
+		}
+	}
+	return "", false
+}
+
+// newSessionName returns the session name for a project: its running
+// session, or else the first of its names no other project uses
+func newSessionName(sessions map[string]string, naming, dir string) string {
+	if session, ok := projectSession(sessions, naming, dir); ok {
+		return session
+	}
+	names := core.SessionNames(naming, dir)
+	for _, name := range names {
+		if _, taken := sessions[name]; !taken {
+			return name
+		}
+	}
+	return names[len(names)-1]
+}
+
+// sessionAnnotator sets Session for editor templates to the tmux session
+// of the project, resolving name collisions with other projects
+func sessionAnnotator(naming string) core.Annotator {
+	return func(dir string, data map[string]string) {
+		data["Session"] = newSessionName(tmuxSessions(), naming, dir)
+	}
+}
+
+// bySessionClient finds the terminal window attached to a tmux session of
+// a project, whatever its title
+func bySessionClient(naming, dir string) windowFinder {
+	return func(backend window.Backend) (int64, bool) {
+		finder, ok := backend.(window.PIDFinder)
+		if !ok {
+			return 0, false
+		}
+		for _, pid := range projectClients(naming, dir) {
+			// The window belongs to the terminal the client runs in
+			for range maxClientAncestors {
+				if windowID, _ := finder.FindWindowByPID(pid); windowID != 0 {
+					return windowID, true
+				}
+				if pid = parentPID(pid); pid <= 1 {
+					break
+				}
+			}
+		}
+		return 0, true
+	}
+}
+
+// projectClients returns the processes of the tmux clients attached to
+// sessions started in the project dir: those of sessions named after the
+// project first, then those of sessions attached by hand under any name
+func projectClients(naming, dir string) []int {
+	dir = filepath.Clean(dir)
+	names := sessionCandidates(naming, dir)
+
+	var named, others []int
+	for _, line := range tmuxLines("list-clients", "-F", "#{client_pid}\t#{session_name}\t#{session_path}") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 || filepath.Clean(parts[2]) != dir {
+			continue
+		}
+		pid, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		if slices.Contains(names, parts[1]) {
+			named = append(named, pid)
+		} else {
+			others = append(others, pid)
+		}
+	}
+	return append(named, others...)
+}
+
+// descendantPIDs returns the processes below pid, nearest first
+func descendantPIDs(pid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	children := make(map[int][]int)
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if parent := parentPID(child); parent != 0 {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var descendants []int
+	queue := children[pid]
+	for len(queue) > 0 {
+		descendants = append(descendants, queue[0])
+		queue = append(queue[1:], children[queue[0]]...)
+	}
+	return descendants
+}
+
+// parentPID returns the parent of a process, or 0 when it cannot be read
+func parentPID(pid int) int {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+	// The command name in parentheses may contain spaces and parentheses
+	end := strings.LastIndex(string(data), ") ")
+	if end < 0 {
+		return 0
+	}
+	parts := strings.Fields(string(data)[end+2:])
+	if len(parts) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(parts[1])
+	return ppid
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// buildInfo describes the running binary and its environment
+type buildInfo struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit,omitempty"`
+	Date          string `json:"date,omitempty"`
+	CommitTime    string `json:"commit_time,omitempty"`
+	Modified      bool   `json:"modified,omitempty"`
+	GoVersion     string `json:"go_version"`
+	Platform      string `json:"platform"`
+	ConfigFile    string `json:"config_file,omitempty"`
+	SelectorFile  string `json:"selector_file"`
+	WindowBackend string `json:"window_backend"`
+}
+
+// Build details, set by main from its link-time variables
+var (
+	buildVersion = "dev"
+	buildCommit  string
+	buildDate    string
+)
+
+var versionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and the context of a bug report",
+	Long: `Print the version, commit and build date of the binary along with the
+config file in use and the detected window backend, the details a bug
+report needs.`,
+	Args: cobra.NoArgs,
+	RunE: printVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the details as JSON")
+	rootCmd.AddCommand(versionCmd)
+}
+
+// SetBuildInfo sets the version, commit and build date reported by the
+// version command. Without a commit, the one Go embeds from the checkout
+// the binary was built in is reported.
+func SetBuildInfo(version, commit, date string) {
+	if version != "" {
+		buildVersion = version
+	}
+	buildCommit, buildDate = commit, date
+}
+
+// currentBuildInfo collects the details of the binary and environment
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:       buildVersion,
+		Commit:        buildCommit,
+		Date:          buildDate,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		ConfigFile:    viper.ConfigFileUsed(),
+		SelectorFile:  cfg.SelectorFile,
+		WindowBackend: windowBackend().Name(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// printVersion prints the build details
+func printVersion(cmd *cobra.Command, args []string) error {
+	info := currentBuildInfo()
+	if versionJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	commit := info.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if info.Modified {
+		commit += " (modified)"
+	}
+	configFile := info.ConfigFile
+	if configFile == "" {
+		configFile = "none"
+	}
+	selectorFile := info.SelectorFile
+	if selectorFile == "" {
+		selectorFile = "none, using the defaults"
+	} else if _, err := os.Stat(selectorFile); err != nil {
+		selectorFile += " (missing, using the defaults)"
+	}
+
+	fmt.Printf("version:         %s\n", info.Version)
+	fmt.Printf("commit:          %s\n", commit)
+	if info.Date != "" {
+		fmt.Printf("built:           %s\n", info.Date)
+	}
+	if info.CommitTime != "" {
+		fmt.Printf("committed:       %s\n", info.CommitTime)
+	}
+	fmt.Printf("go:              %s %s\n", info.GoVersion, info.Platform)
+	fmt.Printf("config file:     %s\n", configFile)
+	fmt.Printf("selector file:   %s\n", selectorFile)
+	fmt.Printf("window backend:  %s\n", info.WindowBackend)
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/core"
+	"github.com/marianozunino/code/v2/internal/window"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow window events to keep usage data up to date",
+	Long: `Run in the background and follow window events from the window manager.
+
+With mru_on_focus: true, a project moves to the front of the MRU list when
+its editor window keeps focus for mru_focus_delay (default 10s), so the
+ranking reflects real usage and not just launches. With track_sessions:
+true, sessions are closed as soon as their window disappears.`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+// runWatch subscribes to window events until interrupted
+func runWatch(cmd *cobra.Command, args []string) error {
+	if !cfg.MruOnFocus && !cfg.TrackSessions {
+		return fmt.Errorf("nothing to watch: enable mru_on_focus or track_sessions")
+	}
+
+	backend := windowBackend()
+	subscriber, ok := backend.(window.Subscriber)
+	if !ok || !backend.Capabilities().Has(window.CanSubscribe) {
+		return fmt.Errorf("window backend %s does not support window events", backend.Name())
+	}
+
+	focus := newFocusWatcher(cfg.MruFocusDelay)
+	tracker := openTracker()
+	log := openHistory()
+
+	go func() {
+		config := newConfigWatcher()
+		ticker := time.NewTicker(configCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cmd.Context().Done():
+				return
+			case <-ticker.C:
+			}
+			if !config.reloadIfChanged() {
+				continue
+			}
+
+			// Only this goroutine writes cfg, so it is read here unlocked
+			newFocus := newFocusWatcher(cfg.MruFocusDelay)
+			configMu.Lock()
+			focus = newFocus
+			tracker = openTracker()
+			log = openHistory()
+			configMu.Unlock()
+		}
+	}()
+
+	return subscriber.Subscribe(cmd.Context(), func(event window.Event) {
+		configMu.Lock()
+		defer configMu.Unlock()
+
+		switch event.Change {
+		case "focus":
+			if cfg.MruOnFocus {
+				focus.focused(event.Title)
+			}
+		case "close":
+			if cfg.TrackSessions {
+				tracker.Reap(windowAlive, log)
+			}
+		}
+	})
+}
+
+// focusWatcher updates the MRU list for windows that keep focus long enough
+type focusWatcher struct {
+	delay    time.Duration
+	mu       sync.Mutex
+	timer    *time.Timer
+	projects map[string]string // Window title -> project
+}
+
+// newFocusWatcher maps the window titles of all known projects
+func newFocusWatcher(delay time.Duration) *focusWatcher {
+	projects := core.RemoveDuplicates(append(openMRU().Items(), projectFinder().FindProjects(cfg.BaseDir)...))
+
+	titles := make(map[string]string, len(projects))
+	for _, project := range projects {
+		title := projectWindowTitle(projectPath(project))
+		if _, exists := titles[title]; !exists {
+			titles[title] = project // MRU entries win on ambiguous titles
+		}
+	}
+
+	return &focusWatcher{delay: delay, projects: titles}
+}
+
+// focused restarts the dwell timer for the newly focused window
+func (w *focusWatcher) focused(title string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	project, ok := w.projects[title]
+	if !ok {
+		return
+	}
+
+	w.timer = time.AfterFunc(w.delay, func() {
+		configMu.Lock()
+		mruList := openMRU()
+		configMu.Unlock()
+		mruList.Update(project)
+	})
+}
@@ -0,0 +1,259 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/daemon"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// whichStatusTimeout bounds the daemon status check of which
+const whichStatusTimeout = 200 * time.Millisecond
+
+var whichJSON bool
+
+var whichCmd = &cobra.Command{
+	Use:   "which",
+	Short: "Show the files and settings in effect and where they come from",
+	Long: `Show the config and selector files, state and cache files, window
+backend and base dirs in effect once flags, --set, environment variables,
+the profile, the config file and its includes and the defaults have been
+applied, along with where each value comes from.`,
+	Example: `  code which
+  code which --profile work
+  code which --json | jq -r '.[] | select(.name == "mru file").value'`,
+	Args: cobra.NoArgs,
+	RunE: printWhich,
+}
+
+func init() {
+	whichCmd.Flags().BoolVar(&whichJSON, "json", false, "print the entries as JSON")
+	rootCmd.AddCommand(whichCmd)
+}
+
+// whichEntry is a file or setting in effect
+type whichEntry struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// printWhich prints the files and settings in effect
+func printWhich(cmd *cobra.Command, args []string) error {
+	entries := whichEntries(cmd)
+	if whichJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		source := e.Source
+		if e.Note != "" {
+			source += ", " + e.Note
+		}
+		fmt.Fprintf(w, "%s\t%s\t(%s)\n", e.Name, e.Value, source)
+	}
+	return w.Flush()
+}
+
+// whichEntries collects the files and settings in effect
+func whichEntries(cmd *cobra.Command) []whichEntry {
+	var entries []whichEntry
+	add := func(name, value, source, note string) {
+		if value == "" {
+			value = "-"
+		}
+		entries = append(entries, whichEntry{name, value, source, note})
+	}
+	file := func(name, key, path string) {
+		note := ""
+		if path != "" {
+			if _, err := os.Stat(path); err != nil {
+				note = "missing"
+			}
+		}
+		add(name, path, settingSource(cmd, key), note)
+	}
+
+	configSource := "default location"
+	if cfgFile != "" {
+		configSource = "--config"
+	}
+	configNote := ""
+	if viper.ConfigFileUsed() == "" {
+		configNote = "none found, defaults only"
+	}
+	add("config file", viper.ConfigFileUsed(), configSource, configNote)
+	for _, include := range includedFiles {
+		add("include", include, "config file", "")
+	}
+
+	profileSource := "--profile"
+	if !cmd.Flags().Changed("profile") {
+		profileSource = "$" + profileEnv
+		if profile == "" {
+			profileSource = "default"
+		}
+	}
+	add("profile", profile, profileSource, "")
+
+	selectorSource := settingSource(cmd, "selector_file")
+	if selectorFile != "" {
+		selectorSource = "--selector-file"
+	}
+	selectorNote := ""
+	if cfg.SelectorFile == "" {
+		selectorNote = "built-in defaults"
+	} else if _, err := os.Stat(cfg.SelectorFile); err != nil {
+		selectorNote = "missing, built-in defaults"
+	}
+	add("selector file", cfg.SelectorFile, selectorSource, selectorNote)
+
+	backendSource := settingSource(cmd, "window_backend")
+	if cfg.WindowBackend == "" || cfg.WindowBackend == "auto" {
+		backendSource = "detected"
+	}
+	add("window backend", windowBackend().Name(), backendSource, "")
+
+	entries = append(entries, baseDirEntries(cmd)...)
+	add("sort", cfg.Sort, settingSource(cmd, "sort"), "")
+
+	file("mru file", "mru_file", cfg.MruFile)
+	file("history file", "history_file", cfg.HistoryFile)
+	file("sessions file", "sessions_file", cfg.SessionsFile)
+	file("positions file", "positions_file", cfg.PositionsFile)
+	file("stats file", "stats_file", cfg.StatsFile)
+	file("git status cache", "git_status_cache", cfg.GitStatusCache)
+	file("description cache", "description_cache", cfg.DescriptionCache)
+	file("launch log", "launch_log", cfg.LaunchLog)
+	file("backup dir", "backup_dir", cfg.BackupDir)
+	file("archive dir", "archive_dir", cfg.ArchiveDir)
+	file("env file", "env_file", cfg.EnvFile)
+
+	daemonNote := ""
+	if cfg.DaemonSocket != "" {
+		daemonNote = "not running"
+		if _, err := daemon.Call(cfg.DaemonSocket, daemon.Request{Op: daemon.OpStatus}, whichStatusTimeout); err == nil {
+			daemonNote = "running"
+		}
+	}
+	add("daemon socket", cfg.DaemonSocket, settingSource(cmd, "daemon_socket"), daemonNote)
+	return entries
+}
+
+// baseDirEntries describes the base dir with the scan settings merged
+// from its dirs entry, and the other dirs entries
+func baseDirEntries(cmd *cobra.Command) []whichEntry {
+	baseDir := cfg.BaseDir
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		baseDir = abs
+	}
+	note := ""
+	if !isDirectory(baseDir) {
+		note = "missing"
+	}
+	entries := []whichEntry{{"base dir", baseDir, settingSource(cmd, "base_dir"), note}}
+
+	var current *DirConfig
+	for i, dir := range cfg.Dirs {
+		if filepath.Clean(dir.Path) == filepath.Clean(cfg.BaseDir) {
+			current = &cfg.Dirs[i]
+			break
+		}
+	}
+	source := func(key string, overridden bool) string {
+		if overridden {
+			return "dirs entry"
+		}
+		return settingSource(cmd, key)
+	}
+
+	merged := dirConfig()
+	depth := "any"
+	if merged.ScanDepth > 0 {
+		depth = strconv.Itoa(merged.ScanDepth)
+	}
+	entries = append(entries,
+		whichEntry{Name: "scan depth", Value: depth, Source: source("scan_depth", current != nil && current.ScanDepth != 0)},
+		whichEntry{Name: "skip dirs", Value: orDash(strings.Join(merged.SkipDirs, ", ")), Source: source("skip_dirs", current != nil && current.SkipDirs != nil)},
+		whichEntry{Name: "editor profile", Value: orDash(merged.EditorProfile), Source: source("editor_profile", current != nil && current.EditorProfile != "")},
+	)
+
+	for _, dir := range cfg.Dirs {
+		if current != nil && dir.Path == current.Path {
+			continue
+		}
+		entries = append(entries, whichEntry{Name: "other base dir", Value: dir.Path, Source: "dirs entry"})
+	}
+	return entries
+}
+
+// settingSource names where the value of a ~/.code.yaml setting comes
+// from, in the order viper gives them precedence
+func settingSource(cmd *cobra.Command, key string) string {
+	if slices.ContainsFunc(settingOverrides, func(setting string) bool {
+		name, _, _ := strings.Cut(setting, "=")
+		return name == key
+	}) {
+		return "--set"
+	}
+	if flag, ok := boundFlags[key]; ok && cmd.Flags().Changed(flag) {
+		return "--" + flag
+	}
+	if _, ok := os.LookupEnv(strings.ToUpper(key)); ok {
+		return "$" + strings.ToUpper(key)
+	}
+	if profile != "" {
+		if viper.IsSet("profiles." + profile + "." + key) {
+			return "profile " + profile
+		}
+		if slices.Contains(profileStateKeys, key) && viper.GetString(key) != "" {
+			return "suffixed for profile " + profile
+		}
+	}
+	if viper.InConfig(key) {
+		return "config file"
+	}
+	return "default"
+}
+
+// orDash returns value, or "-" when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,236 @@
+/*
+Copyright © 2024 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultWorktreeLayout places worktrees next to their project, e.g.
+// api-wt/feature/login
+const defaultWorktreeLayout = "{{.Project}}-wt/{{.Branch}}"
+
+var (
+	worktreeFrom   string
+	worktreeNoOpen bool
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree <project> <branch> [-- editor args...]",
+	Short: "Create a git worktree of a project for a branch and open it",
+	Long: `Create a git worktree of a project with the branch checked out and open
+it like a project, so every branch can have a window of its own.
+
+The worktree is placed under the base dir by the worktree_layout
+template, by default {{.Project}}-wt/{{.Branch}}. A branch that does not
+exist is created from --from, by default the commit checked out in the
+project, unless a remote has it, which it then tracks. A worktree that
+already exists is just opened.`,
+	Example: `  code worktree api feature/login
+  code worktree api hotfix --from origin/release
+  code worktree api review --no-open`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		names := args
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			names = args[:dash]
+		}
+		if len(names) != 2 {
+			return fmt.Errorf("worktree takes a project and a branch, editor arguments follow --")
+		}
+		return nil
+	},
+	ValidArgsFunction: completeWorktree,
+	RunE:              createWorktree,
+}
+
+func init() {
+	worktreeCmd.Flags().StringVar(&worktreeFrom, "from", "", "commit new branches start at (default the checked out one)")
+	worktreeCmd.Flags().BoolVar(&worktreeNoOpen, "no-open", false, "only create the worktree and add it to the recent projects")
+	worktreeCmd.Flags().StringVar(&editorName, "editor", "", "open the project with a named editor profile from the selector file, or an editor preset")
+	worktreeCmd.Flags().BoolVar(&here, "here", false, "run the editor in the current terminal instead of opening a window")
+	worktreeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the git worktree command and editor command instead of running them")
+	worktreeCmd.RegisterFlagCompletionFunc("editor", completeEditors)
+	rootCmd.AddCommand(worktreeCmd)
+}
+
+// worktreeData is what worktree_layout templates can use
+type worktreeData struct {
+	Project string // Relative to the base dir, e.g. work/api
+	Name    string // Last element of the project, e.g. api
+	Branch  string
+}
+
+// createWorktree adds a worktree for a branch to a project and opens it
+func createWorktree(cmd *cobra.Command, args []string) error {
+	project, err := resolveLocalProject(args[0])
+	if err != nil {
+		return err
+	}
+	branch := args[1]
+	repoDir := projectPath(project)
+	if !isGitRepo(repoDir) {
+		return fmt.Errorf("%s is not a git repository", project)
+	}
+	if err := exec.Command("git", "check-ref-format", "--branch", branch).Run(); err != nil {
+		return fmt.Errorf("invalid branch name %q", branch)
+	}
+
+	worktree, err := worktreeDestination(project, branch)
+	if err != nil {
+		return err
+	}
+	dest := projectPath(worktree)
+	gitArgs := worktreeAddArgs(repoDir, dest, branch)
+
+	if dryRun {
+		if !isGitRepo(dest) {
+			fmt.Printf("git -C %s %s\n", repoDir, strings.Join(gitArgs, " "))
+			return nil
+		}
+	} else if err := gitWorktreeAdd(repoDir, dest, gitArgs); err != nil {
+		return err
+	}
+
+	mruList := openMRU()
+	defer mruList.Flush()
+
+	if worktreeNoOpen {
+		if dryRun {
+			return nil
+		}
+		return mruList.Update(worktree)
+	}
+
+	remotes, err := newRemoteRegistry()
+	if err != nil {
+		return err
+	}
+	return openListed(mruList, remotes, worktree, args[2:])
+}
+
+// worktreeDestination returns the path relative to the base dir the
+// worktree of a branch of a project goes to
+func worktreeDestination(project, branch string) (string, error) {
+	layout := cfg.WorktreeLayout
+	if layout == "" {
+		layout = defaultWorktreeLayout
+	}
+	tmpl, err := template.New("worktree_layout").Option("missingkey=error").Parse(layout)
+	if err != nil {
+		return "", fmt.Errorf("invalid worktree_layout: %w", err)
+	}
+	var buf bytes.Buffer
+	data := worktreeData{Project: project, Name: filepath.Base(project), Branch: branch}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid worktree_layout: %w", err)
+	}
+
+	dest := filepath.Clean(buf.String())
+	if filepath.IsAbs(dest) || dest == "." || dest == ".." || strings.HasPrefix(dest, "../") {
+		return "", fmt.Errorf("worktree path %q is not inside the base dir", dest)
+	}
+	if dest == project || strings.HasPrefix(dest, project+"/") {
+		return "", fmt.Errorf("worktree path %q is inside the project", dest)
+	}
+	return dest, nil
+}
+
+// worktreeAddArgs returns the git arguments adding a worktree at dest for
+// a branch: checked out when the repository or exactly one remote has it,
+// created from --from otherwise
+func worktreeAddArgs(repoDir, dest, branch string) []string {
+	if gitRefExists(repoDir, "refs/heads/"+branch) || remoteBranches(repoDir, branch) == 1 {
+		// git worktree add tracks the remote branch of a missing local one
+		return []string{"worktree", "add", dest, branch}
+	}
+	args := []string{"worktree", "add", "-b", branch, dest}
+	if worktreeFrom != "" {
+		args = append(args, worktreeFrom)
+	}
+	return args
+}
+
+// gitWorktreeAdd runs git worktree add in the repository, leaving a
+// worktree already at dest alone
+func gitWorktreeAdd(repoDir, dest string, gitArgs []string) error {
+	if isGitRepo(dest) {
+		slog.Info("worktree exists", "path", dest)
+		return nil
+	}
+
+	git := exec.Command("git", append([]string{"-C", repoDir}, gitArgs...)...)
+	git.Stdout = os.Stdout
+	git.Stderr = os.Stderr
+	if err := git.Run(); err != nil {
+		return fmt.Errorf("git worktree add failed: %w", err)
+	}
+	return nil
+}
+
+// gitRefExists reports whether a ref exists in the repository in dir
+func gitRefExists(dir, ref string) bool {
+	return exec.Command("git", "-C", dir, "show-ref", "--verify", "--quiet", ref).Run() == nil
+}
+
+// remoteBranches counts the remotes of the repository in dir with a branch
+func remoteBranches(dir, branch string) int {
+	output, err := exec.Command("git", "-C", dir, "for-each-ref", "--format=%(refname)", "refs/remotes").Output()
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, ref := range strings.Fields(string(output)) {
+		// refs/remotes/<remote>/<branch>, branches may contain slashes
+		rest := strings.TrimPrefix(ref, "refs/remotes/")
+		if _, name, ok := strings.Cut(rest, "/"); ok && name == branch {
+			count++
+		}
+	}
+	return count
+}
+
+// completeWorktree completes the project, then the branches of its
+// repository
+func completeWorktree(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeProject(cmd, args, toComplete)
+	case 1:
+		project, err := resolveLocalProject(args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		output, _ := exec.Command("git", "-C", projectPath(project), "for-each-ref", "--format=%(refname:lstrip=2)", "refs/heads").Output()
+		return strings.Fields(string(output)), cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
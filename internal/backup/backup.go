@@ -0,0 +1,236 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	timestampLayout = "20060102T150405.000000000"
+	backupSuffix    = ".bak"
+)
+
+// Backup describes a single rotated copy of a state file
+type Backup struct {
+	Path    string
+	Name    string
+	Source  string // Base name of the file the backup was taken from
+	Created time.Time
+}
+
+// Rotator keeps timestamped copies of state files and prunes old ones
+type Rotator struct {
+	Dir    string
+	Keep   int
+	MaxAge time.Duration
+}
+
+// NewRotator creates a rotator that stores backups in dir
+func NewRotator(dir string, keep int, maxAge time.Duration) *Rotator {
+	return &Rotator{
+		Dir:    dir,
+		Keep:   keep,
+		MaxAge: maxAge,
+	}
+}
+
+// DefaultDir returns the default backup directory under the XDG state dir
+func DefaultDir() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "code", "backups")
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "code", "backups")
+}
+
+// sourceName returns the name used to group backups of a state file
+func sourceName(path string) string {
+	return strings.TrimPrefix(filepath.Base(path), ".")
+}
+
+// Snapshot copies the current contents of path into the backup directory
+// and prunes backups that exceed the configured count or age.
+// A missing source file is not an error: there is nothing to preserve yet.
+func (r *Rotator) Snapshot(path string) error {
+	if r == nil || r.Keep <= 0 {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s.%s%s", sourceName(path), time.Now().Format(timestampLayout), backupSuffix)
+	dst, err := os.OpenFile(filepath.Join(r.Dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to close backup: %w", err)
+	}
+
+	return r.Prune(path)
+}
+
+// Prune removes backups of path older than MaxAge, keeping at most Keep copies
+func (r *Rotator) Prune(path string) error {
+	backups, err := r.List(path)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Time{}
+	if r.MaxAge > 0 {
+		cutoff = time.Now().Add(-r.MaxAge)
+	}
+
+	for i, b := range backups {
+		if i < r.Keep && b.Created.After(cutoff) {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune backup %s: %w", b.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// List returns the backups of path, newest first.
+// An empty path lists backups of every state file.
+func (r *Rotator) List(path string) ([]Backup, error) {
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, fmt.Errorf("failed to read backup dir: %w", err)
+	}
+
+	var source string
+	if path != "" {
+		source = sourceName(path)
+	}
+
+	backups := make([]Backup, 0, len(entries))
+	for _, entry := range entries {
+		b, ok := parseName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if source != "" && b.Source != source {
+			continue
+		}
+		b.Path = filepath.Join(r.Dir, b.Name)
+		backups = append(backups, b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+
+	return backups, nil
+}
+
+// Resolve finds a backup by file name or path
+func (r *Rotator) Resolve(name string) (Backup, error) {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		if _, err := os.Stat(name); err != nil {
+			return Backup{}, fmt.Errorf("backup not found: %s", name)
+		}
+		b, ok := parseName(filepath.Base(name))
+		if !ok {
+			return Backup{}, fmt.Errorf("not a backup file: %s", name)
+		}
+		b.Path = name
+		return b, nil
+	}
+
+	b, ok := parseName(name)
+	if !ok {
+		return Backup{}, fmt.Errorf("not a backup file: %s", name)
+	}
+	b.Path = filepath.Join(r.Dir, name)
+	if _, err := os.Stat(b.Path); err != nil {
+		return Backup{}, fmt.Errorf("backup not found: %s", name)
+	}
+	return b, nil
+}
+
+// Matches reports whether the backup was taken from the given state file
+func (b Backup) Matches(path string) bool {
+	return b.Source == sourceName(path)
+}
+
+// Restore replaces target with the contents of the backup, snapshotting
+// the current target first so the restore itself can be undone.
+func (r *Rotator) Restore(b Backup, target string) error {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if err := r.Snapshot(target); err != nil {
+		return err
+	}
+
+	tempFile := target + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tempFile, target); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+
+	return nil
+}
+
+// parseName splits a backup file name into its source and timestamp
+func parseName(name string) (Backup, bool) {
+	if !strings.HasSuffix(name, backupSuffix) {
+		return Backup{}, false
+	}
+
+	trimmed := strings.TrimSuffix(name, backupSuffix)
+	// The timestamp itself contains a dot, so split on the one before it
+	idx := len(trimmed) - len(timestampLayout) - 1
+	if idx <= 0 || trimmed[idx] != '.' {
+		return Backup{}, false
+	}
+
+	created, err := time.ParseInLocation(timestampLayout, trimmed[idx+1:], time.Local)
+	if err != nil {
+		return Backup{}, false
+	}
+
+	return Backup{
+		Name:    name,
+		Source:  trimmed[:idx],
+		Created: created,
+	}, true
+}
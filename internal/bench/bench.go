@@ -0,0 +1,138 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// dropCachesFile frees the kernel's page, dentry and inode caches on Linux
+const dropCachesFile = "/proc/sys/vm/drop_caches"
+
+// ErrCannotDropCaches is returned by DropCaches when caches cannot be
+// dropped, for lack of root or on systems other than Linux
+var ErrCannotDropCaches = errors.New("cannot drop file system caches (needs root on Linux)")
+
+// Sample is the cost of a single run
+type Sample struct {
+	Duration time.Duration
+	Allocs   uint64 // Heap allocations
+	Bytes    uint64 // Bytes allocated
+}
+
+// Measure runs fn once and returns what it took
+func Measure(fn func()) Sample {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	fn()
+	duration := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	return Sample{
+		Duration: duration,
+		Allocs:   after.Mallocs - before.Mallocs,
+		Bytes:    after.TotalAlloc - before.TotalAlloc,
+	}
+}
+
+// Summary describes the samples of a series of runs
+type Summary struct {
+	Runs        int
+	Min         time.Duration
+	P50         time.Duration
+	P95         time.Duration
+	Max         time.Duration
+	Mean        time.Duration
+	AllocsPerOp uint64
+	BytesPerOp  uint64
+}
+
+// Summarize computes the percentiles and mean allocations of samples
+func Summarize(samples []Sample) Summary {
+	if len(samples) == 0 {
+		return Summary{}
+	}
+
+	durations := make([]time.Duration, len(samples))
+	var total time.Duration
+	var allocs, bytes uint64
+	for i, s := range samples {
+		durations[i] = s.Duration
+		total += s.Duration
+		allocs += s.Allocs
+		bytes += s.Bytes
+	}
+	slices.Sort(durations)
+
+	n := uint64(len(samples))
+	return Summary{
+		Runs:        len(samples),
+		Min:         durations[0],
+		P50:         percentile(durations, 50),
+		P95:         percentile(durations, 95),
+		Max:         durations[len(durations)-1],
+		Mean:        total / time.Duration(len(samples)),
+		AllocsPerOp: allocs / n,
+		BytesPerOp:  bytes / n,
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// DropCaches writes dirty pages and frees the dentry and inode caches, so
+// the next scan has to read directories from disk
+func DropCaches() error {
+	syscall.Sync()
+	if err := os.WriteFile(dropCachesFile, []byte("2\n"), 0o200); err != nil {
+		return fmt.Errorf("%w: %v", ErrCannotDropCaches, err)
+	}
+	return nil
+}
+
+// Generate creates a tree of projects under dir resembling a checkout
+// directory: projects spread over organisations and nested groups, with
+// plain directories between them and some content in each project
+func Generate(dir string, projects int) error {
+	for i := 0; i < projects; i++ {
+		org := fmt.Sprintf("org%02d", i%16)
+		var project string
+		if i%3 == 0 {
+			project = filepath.Join(dir, org, fmt.Sprintf("group%02d", i%7), fmt.Sprintf("repo%05d", i))
+		} else {
+			project = filepath.Join(dir, org, fmt.Sprintf("repo%05d", i))
+		}
+
+		for _, sub := range []string{".git/objects", ".git/refs/heads", "src/internal", "docs"} {
+			if err := os.MkdirAll(filepath.Join(project, sub), 0o755); err != nil {
+				return fmt.Errorf("failed to generate tree: %w", err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(project, "README.md"), []byte("# synthetic\n"), 0o644); err != nil {
+			return fmt.Errorf("failed to generate tree: %w", err)
+		}
+
+		// Directories that are not projects, which the scan has to walk
+		if i%5 == 0 {
+			notes := filepath.Join(dir, org, fmt.Sprintf("notes%05d", i), "drafts", "old")
+			if err := os.MkdirAll(notes, 0o755); err != nil {
+				return fmt.Errorf("failed to generate tree: %w", err)
+			}
+		}
+	}
+	return nil
+}
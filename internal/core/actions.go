@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is what to do with the projects picked in the selector
+type Action string
+
+const (
+	ActionOpen      Action = "open"       // Open the project in the editor
+	ActionForget    Action = "forget"     // Remove the project from the MRU list
+	ActionTerminal  Action = "terminal"   // Open a terminal in the project without the editor
+	ActionNewWindow Action = "new-window" // Open another editor window even when one is open
+	ActionCodespace Action = "codespace"  // Open a codespace of the project's GitHub repository
+)
+
+// editorActionPrefix starts actions that open projects with a named editor
+// profile, e.g. "editor:vscode"
+const editorActionPrefix = "editor:"
+
+// EditorProfile returns the editor profile an action opens projects with
+func (a Action) EditorProfile() (string, bool) {
+	return strings.CutPrefix(string(a), editorActionPrefix)
+}
+
+// defaultActions are the key bindings of selectors that report the key
+// used to accept the selection
+var defaultActions = map[string]Action{
+	"ctrl-d": ActionForget,
+	"ctrl-o": ActionNewWindow,
+	"ctrl-t": ActionTerminal,
+}
+
+// ValidateActions checks that every key is bound to a known action or
+// editor profile
+func ValidateActions(actions map[string]Action, editors map[string]EditorConfig) error {
+	for key, action := range actions {
+		if profile, ok := action.EditorProfile(); ok {
+			if _, exists := editors[profile]; !exists {
+				return fmt.Errorf("unknown editor profile %q for key %s", profile, key)
+			}
+			continue
+		}
+		switch action {
+		case ActionOpen, ActionForget, ActionTerminal, ActionNewWindow, ActionCodespace:
+		default:
+			return fmt.Errorf("unknown action %q for key %s", action, key)
+		}
+	}
+	return nil
+}
+
+// actionKeys returns the keys bound to actions in a stable order
+func (c SelectorConfig) actionKeys() []string {
+	keys := make([]string, 0, len(c.Actions))
+	for key := range c.Actions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// expectArgs renders the arguments that make the selector report the key
+// used to accept the selection
+func (c SelectorConfig) expectArgs() []string {
+	if len(c.Actions) == 0 {
+		return nil
+	}
+	return renderSelectorArgs(c.ExpectArgs, map[string]string{
+		"Keys": strings.Join(c.actionKeys(), ","),
+	})
+}
+
+// action returns the action bound to key, opening projects by default
+func (c SelectorConfig) action(key string) Action {
+	if action, ok := c.Actions[key]; ok {
+		return action
+	}
+	return ActionOpen
+}
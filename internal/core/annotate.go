@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingPlaceholder is shown for lazy annotations that are still resolving
+const pendingPlaceholder = "…"
+
+// lazyAnnotator is an annotator too slow to run before the selector opens
+type lazyAnnotator struct {
+	keys     []string
+	annotate Annotator
+	cached   Annotator // Fills in last known values, may be nil
+}
+
+// AddLazyAnnotator registers a slow function that sets the given keys of
+// the project title data. Titles are shown with placeholders for the keys
+// and updated as the annotations resolve, when the selector supports it.
+// Annotators whose keys the title template does not use never run.
+func (s *Selector) AddLazyAnnotator(keys []string, a Annotator) {
+	s.lazyAnnotators = append(s.lazyAnnotators, lazyAnnotator{keys: keys, annotate: a})
+}
+
+// AddCachedLazyAnnotator is like AddLazyAnnotator, with cached filling in
+// the last known values instead of placeholders while a is running
+func (s *Selector) AddCachedLazyAnnotator(keys []string, a, cached Annotator) {
+	s.lazyAnnotators = append(s.lazyAnnotators, lazyAnnotator{keys: keys, annotate: a, cached: cached})
+}
+
+// SetAnnotationBudget limits how long selectors without live updates wait
+// for lazy annotations before opening; unresolved entries keep their
+// placeholders. Zero waits for all annotations.
+func (s *Selector) SetAnnotationBudget(budget time.Duration) {
+	s.annotationBudget = budget
+}
+
+// activeLazyAnnotators returns the lazy annotators used by the title template
+func (s *Selector) activeLazyAnnotators() []lazyAnnotator {
+	var active []lazyAnnotator
+	for _, lazy := range s.lazyAnnotators {
+		for _, key := range lazy.keys {
+			if strings.Contains(s.config.Format.ProjectTitle, "."+key) {
+				active = append(active, lazy)
+				break
+			}
+		}
+	}
+	return active
+}
+
+// annotationScheduler resolves lazy annotations in the background,
+// projects earlier in the list first
+type annotationScheduler struct {
+	selector *Selector
+	projects []string
+	lazy     []lazyAnnotator
+	workers  int
+}
+
+// newAnnotationScheduler returns a scheduler for projects, or nil when no
+// lazy annotation is needed
+func (s *Selector) newAnnotationScheduler(projects []string) *annotationScheduler {
+	lazy := s.activeLazyAnnotators()
+	if len(lazy) == 0 {
+		return nil
+	}
+	return &annotationScheduler{
+		selector: s,
+		projects: projects,
+		lazy:     lazy,
+		workers:  runtime.NumCPU(),
+	}
+}
+
+// placeholders fills the keys of the scheduled annotations with their
+// cached values, or placeholders when nothing is cached
+func (a *annotationScheduler) placeholders(project string, data map[string]string) {
+	if a == nil {
+		return
+	}
+	for _, lazy := range a.lazy {
+		for _, key := range lazy.keys {
+			data[key] = pendingPlaceholder
+		}
+		if lazy.cached != nil {
+			lazy.cached(project, data)
+		}
+	}
+}
+
+// run resolves the annotations of every project and calls done with the
+// index and complete title of each one. It returns when all projects are
+// resolved or ctx is cancelled; done is never called after it returned.
+func (a *annotationScheduler) run(ctx context.Context, done func(index int, title string)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	stopped := false // Set once run returned, results are dropped afterwards
+
+	for range a.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				project := a.projects[i]
+				data := a.selector.projectData(project)
+				for _, lazy := range a.lazy {
+					lazy.annotate(project, data)
+				}
+
+				mu.Lock()
+				if !stopped {
+					done(i, a.selector.renderTitle(project, data))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range a.projects {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	// Annotations still running when ctx ends finish in the background
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	stopped = true
+	mu.Unlock()
+}
+
+// titleIndex maps selector entries back to their projects. Titles change
+// as lazy annotations resolve, so every version is remembered.
+type titleIndex struct {
+	mu       sync.Mutex
+	projects map[string]string
+}
+
+// newTitleIndex returns an empty title index
+func newTitleIndex(size int) *titleIndex {
+	return &titleIndex{projects: make(map[string]string, size)}
+}
+
+// add records that title belongs to project, keeping the first project
+// seen for titles shared by several projects
+func (t *titleIndex) add(title, project string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.projects[title]; !exists {
+		t.projects[title] = project
+	}
+}
+
+// lookup returns the project a title belongs to
+func (t *titleIndex) lookup(title string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	project, ok := t.projects[title]
+	return project, ok
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// shellNames lists interpreters whose `-c` argument is a shell script
+var shellNames = map[string]bool{
+	"sh":   true,
+	"bash": true,
+	"dash": true,
+	"zsh":  true,
+	"ksh":  true,
+	"fish": true,
+}
+
+// renderArgs turns an argument template into an argv slice.
+//
+// The template source is split into arguments before any value is
+// substituted, so values containing spaces or quotes always stay a single
+// argument. Arguments that are scripts passed to `sh -c` get every value
+// shell-quoted, so a directory name can never inject shell syntax.
+//
+// Values in lists are argument lists: an argument that is just {{.Key}}
+// becomes one argument per item, and scripts get the items quoted one by one.
+func renderArgs(name, src string, funcs template.FuncMap, data map[string]string, lists map[string][]string) ([]string, error) {
+	tokens, err := splitArgsTemplate(src)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make(map[string]string, len(data)+len(lists))
+	quoted := make(map[string]string, len(data)+len(lists))
+	for k, v := range data {
+		plain[k] = v
+		quoted[k] = shellQuote(v)
+	}
+	for k, items := range lists {
+		quotedItems := make([]string, len(items))
+		for i, item := range items {
+			quotedItems[i] = shellQuote(item)
+		}
+		plain[k] = strings.Join(items, " ")
+		quoted[k] = strings.Join(quotedItems, " ")
+	}
+
+	args := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		script := isShellScript(args, len(args))
+		if match := listToken.FindStringSubmatch(token); match != nil && !script {
+			if items, ok := lists[match[1]]; ok {
+				args = append(args, items...)
+				continue
+			}
+		}
+
+		tmpl, err := template.New(name).Funcs(funcs).Parse(token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+		}
+
+		values := plain
+		if script {
+			values = quoted
+		}
+
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return nil, fmt.Errorf("failed to render %s template: %w", name, err)
+		}
+		args = append(args, buf.String())
+	}
+
+	return args, nil
+}
+
+// listToken matches arguments that are a single value, e.g. {{.ExtraArgs}}
+var listToken = regexp.MustCompile(`^\{\{\s*\.(\w+)\s*\}\}$`)
+
+// isShellScript reports whether argument i is the script of `sh -c`
+func isShellScript(args []string, i int) bool {
+	if i < 2 || args[i-1] != "-c" {
+		return false
+	}
+	return shellNames[filepath.Base(args[i-2])]
+}
+
+// splitArgsTemplate splits a template into arguments using POSIX shell
+// quoting rules. Template actions ({{ ... }}) are copied verbatim, so
+// quotes inside them do not start or end an argument.
+func splitArgsTemplate(src string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+	)
+
+	runes := []rune(src)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		// Copy template actions untouched
+		if r == '{' && i+1 < len(runes) && runes[i+1] == '{' {
+			end := strings.Index(string(runes[i:]), "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated template action in %q", src)
+			}
+			action := []rune(string(runes[i:])[:end+2])
+			current.WriteString(string(action))
+			i += len(action) - 1
+			inArg = true
+			continue
+		}
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]):
+				i++
+				current.WriteRune(runes[i])
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", src)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// shellQuote quotes a string for safe use as a single POSIX shell word
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// ShellJoin renders a command line for display, quoting the arguments that
+// need it
+func ShellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()*?[]{}#~!") {
+			arg = shellQuote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// sanitizeTitle replaces control characters that would break window titles
+// and the newline-delimited selector protocol
+func sanitizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return '?'
+		}
+		return r
+	}, title)
+}
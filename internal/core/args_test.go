@@ -0,0 +1,136 @@
+package core
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// hostileNames are directory names that break naive quoting
+var hostileNames = []struct {
+	name string
+	dir  string
+}{
+	{"plain", "/home/me/Dev/api"},
+	{"space", "/home/me/Dev/my project"},
+	{"single quote", "/home/me/Dev/it's"},
+	{"double quote", `/home/me/Dev/say "hi"`},
+	{"closing bracket", `/home/me/Dev/a"]b`},
+	{"command substitution", "/home/me/Dev/$(touch pwned)"},
+	{"backticks", "/home/me/Dev/`touch pwned`"},
+	{"variable", "/home/me/Dev/$HOME"},
+	{"separators", "/home/me/Dev/a; rm -rf x && b | c"},
+	{"backslash", `/home/me/Dev/back\slash`},
+	{"newline", "/home/me/Dev/new\nline"},
+}
+
+func TestRenderArgsEditorArgv(t *testing.T) {
+	for _, tt := range hostileNames {
+		t.Run(tt.name, func(t *testing.T) {
+			title := sanitizeTitle("nvim ~ " + tt.dir)
+			args, err := renderArgs("editor", DefaultConfig().Editor.Args, nil, map[string]string{
+				"Dir":     tt.dir,
+				"Title":   title,
+				"Session": "api",
+			}, map[string][]string{"ExtraArgs": {"+12", "main go"}})
+			if err != nil {
+				t.Fatalf("renderArgs: %v", err)
+			}
+
+			want := []string{"-d", tt.dir, "-T", title, "--class", title, "sh", "-c"}
+			if len(args) != len(want)+1 || !slices.Equal(args[:len(want)], want) {
+				t.Fatalf("args = %q, want %q followed by the script", args, want)
+			}
+		})
+	}
+}
+
+func TestRenderArgsTmuxScript(t *testing.T) {
+	for _, tt := range hostileNames {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := renderArgs("editor", DefaultConfig().Editor.Args, nil, map[string]string{
+				"Dir":     tt.dir,
+				"Title":   "title",
+				"Session": "api",
+			}, map[string][]string{"ExtraArgs": {"+12", "it's here"}})
+			if err != nil {
+				t.Fatalf("renderArgs: %v", err)
+			}
+
+			// The shell splits the script back into the original words
+			words, err := splitArgsTemplate(args[len(args)-1])
+			if err != nil {
+				t.Fatalf("script %q does not parse: %v", args[len(args)-1], err)
+			}
+			want := []string{"tmux", "new", "-c", tt.dir, "-A", "-s", "api", "nvim", tt.dir, "+12", "it's here"}
+			if !slices.Equal(words, want) {
+				t.Errorf("script %q splits into %q, want %q", args[len(args)-1], words, want)
+			}
+		})
+	}
+}
+
+func TestRenderArgsShellRunsScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	for _, tt := range hostileNames {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := renderArgs("editor", `sh -c "printf '%s\0' {{.Dir}} {{.ExtraArgs}}"`, nil,
+				map[string]string{"Dir": tt.dir},
+				map[string][]string{"ExtraArgs": {tt.dir}})
+			if err != nil {
+				t.Fatalf("renderArgs: %v", err)
+			}
+
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Dir = t.TempDir()
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%q failed: %v", args, err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+			if want := []string{tt.dir, tt.dir}; !slices.Equal(got, want) {
+				t.Errorf("sh printed %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSplitArgsTemplate(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`-d {{.Dir}} -T {{.Title}}`, []string{"-d", "{{.Dir}}", "-T", "{{.Title}}"}},
+		{`--title="a b" c`, []string{"--title=a b", "c"}},
+		{`'it'"'"'s'`, []string{"it's"}},
+		{`a\ b "c\"d" 'e\f'`, []string{"a b", `c"d`, `e\f`}},
+		{`{{printf "%s %s" .A .B}} x`, []string{`{{printf "%s %s" .A .B}}`, "x"}},
+		{"a\tb\nc", []string{"a", "b", "c"}},
+		{`""`, []string{""}},
+	}
+	for _, tt := range tests {
+		got, err := splitArgsTemplate(tt.src)
+		if err != nil {
+			t.Errorf("splitArgsTemplate(%q): %v", tt.src, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitArgsTemplate(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	for _, src := range []string{`"open`, `'open`, `{{.Dir`} {
+		if _, err := splitArgsTemplate(src); err == nil {
+			t.Errorf("splitArgsTemplate(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestSanitizeTitle(t *testing.T) {
+	if got := sanitizeTitle("a\nb\tc\x7fd"); got != "a?b?c?d" {
+		t.Errorf("sanitizeTitle = %q, want %q", got, "a?b?c?d")
+	}
+}
@@ -0,0 +1,28 @@
+package core
+
+import "slices"
+
+// defaultCancelExitCodes are the exit codes dmenu-like tools use on Escape
+var defaultCancelExitCodes = []int{1}
+
+// CancelPolicy decides which selector outcomes mean the user cancelled
+// rather than a failure
+type CancelPolicy struct {
+	ExitCodes   []int `yaml:"exit_codes"`   // [1] by default
+	EmptyOutput *bool `yaml:"empty_output"` // Exiting successfully without a selection, true by default
+}
+
+// cancelledBy reports whether the selector exit code means the user cancelled
+func (p CancelPolicy) cancelledBy(code int) bool {
+	codes := p.ExitCodes
+	if len(codes) == 0 {
+		codes = defaultCancelExitCodes
+	}
+	return slices.Contains(codes, code)
+}
+
+// cancelledByEmptyOutput reports whether an empty selection means the user
+// cancelled
+func (p CancelPolicy) cancelledByEmptyOutput() bool {
+	return p.EmptyOutput == nil || *p.EmptyOutput
+}
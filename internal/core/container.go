@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// UseContainer runs terminal editors inside the devcontainer of projects
+// that have one, as the devcontainer setting does
+func (s *Selector) UseContainer() {
+	s.useContainer = true
+}
+
+// inContainer reports whether the editor of the project in dir runs inside
+// its devcontainer. Projects without one, GUI editors and machines without
+// the devcontainer CLI use the normal flow.
+func (s *Selector) inContainer(dir string) bool {
+	if !s.useContainer && !s.config.Devcontainer {
+		return false
+	}
+	if s.config.Editor.Here == "" && s.config.Editor.Command != "" {
+		return false // Nothing to run in a terminal
+	}
+	if !hasDevcontainer(dir) {
+		return false
+	}
+	_, err := exec.LookPath("devcontainer")
+	return err == nil
+}
+
+// hasDevcontainer reports whether the project in dir defines a devcontainer
+func hasDevcontainer(dir string) bool {
+	for _, name := range []string{".devcontainer", ".devcontainer.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// containerExec wraps program to run inside the devcontainer of dir
+func containerExec(dir string, program []string) []string {
+	return append([]string{"devcontainer", "exec", "--workspace-folder", dir}, program...)
+}
+
+// containerProgram renders the editor program for the devcontainer of dir,
+// with {{.Dir}} the workspace folder inside the container
+func (s *Selector) containerProgram(dir, title string) ([]string, error) {
+	data := s.editorData(dir, sanitizeTitle(title))
+	data["Dir"] = s.containerWorkspace(dir)
+	program, err := s.terminalProgram(dir, data)
+	if err != nil {
+		return nil, err
+	}
+	return containerExec(dir, program), nil
+}
+
+// containerWorkspace returns where the project in dir is mounted inside
+// its devcontainer: as reported when it was started, or else the default
+func (s *Selector) containerWorkspace(dir string) string {
+	if folder, ok := s.containerFolders[dir]; ok {
+		return folder
+	}
+	return "/workspaces/" + filepath.Base(dir)
+}
+
+// containerCommand returns the command line opening a terminal that runs
+// the editor inside the devcontainer of dir
+func (s *Selector) containerCommand(dir, title string) ([]string, error) {
+	program, err := s.containerProgram(dir, title)
+	if err != nil {
+		return nil, err
+	}
+	command, args, err := s.terminalCommand(dir, title, program)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{command}, args...), nil
+}
+
+// ContainerUpCommand returns the command that starts the devcontainer of
+// a project, nil when its editor does not run in one
+func (s *Selector) ContainerUpCommand(dir string) []string {
+	if !s.inContainer(dir) {
+		return nil
+	}
+	return []string{"devcontainer", "up", "--workspace-folder", dir}
+}
+
+// StartContainer starts the devcontainer of a project, building it on
+// first use, and learns where the project is mounted in it. It does
+// nothing when the editor does not run in one.
+func (s *Selector) StartContainer(dir string) error {
+	argv := s.ContainerUpCommand(dir)
+	if argv == nil {
+		return nil
+	}
+	output, err := exec.Command(argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		if len(output) > maxFailureLog {
+			output = output[len(output)-maxFailureLog:]
+		}
+		return fmt.Errorf("devcontainer up failed: %w\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	// The result is the last line of output
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	var result struct {
+		RemoteWorkspaceFolder string `json:"remoteWorkspaceFolder"`
+	}
+	if json.Unmarshal(lines[len(lines)-1], &result) == nil && result.RemoteWorkspaceFolder != "" {
+		if s.containerFolders == nil {
+			s.containerFolders = make(map[string]string)
+		}
+		s.containerFolders[dir] = result.RemoteWorkspaceFolder
+	}
+	return nil
+}
@@ -1,19 +1,30 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
-	"sync"
 	"text/template"
 	"time"
 
+	"github.com/marianozunino/code/v2/internal/tui"
 	"gopkg.in/yaml.v3"
 )
 
+// BuiltinSelector selects projects with the built-in terminal finder.
+// It is also used when no selector command is configured.
+const BuiltinSelector = "builtin"
+
+// NumberedSelector prints a numbered list and reads the choice from
+// stdin. It is also tried when no selector of the chain can be started.
+const NumberedSelector = "numbered"
+
 // Project represents a development project
 type Project struct {
 	Path string
@@ -21,7 +32,14 @@ type Project struct {
 }
 
 // ProjectFinder finds Git repositories in a directory
-type ProjectFinder struct{}
+type ProjectFinder struct {
+	MaxDepth int      // Levels below the directory to look in, 0 for any
+	SkipDirs []string // Directory names not to look in, globs allowed
+
+	// OnDir is called with each directory looked in that is not a project,
+	// e.g. to watch them for new projects
+	OnDir func(path string)
+}
 
 // FindProjects scans a directory for Git repositories
 func (pf *ProjectFinder) FindProjects(devDir string) []string {
@@ -35,11 +53,25 @@ func (pf *ProjectFinder) FindProjects(devDir string) []string {
 		if !info.IsDir() {
 			return nil
 		}
+		if path != devDir && pf.skipped(info.Name()) {
+			return filepath.SkipDir
+		}
 		if isGitRepo(path) {
 			relPath := strings.TrimPrefix(path, devDir+"/")
+			if sanitizeTitle(relPath) != relPath {
+				// Control characters would break the line-based selector protocol
+				slog.Warn("skipping project with control characters in its path", "path", sanitizeTitle(path))
+				return filepath.SkipDir
+			}
 			projects = append(projects, relPath)
 			return filepath.SkipDir // Don't scan inside git repos
 		}
+		if pf.OnDir != nil {
+			pf.OnDir(path)
+		}
+		if pf.MaxDepth > 0 && path != devDir && strings.Count(strings.TrimPrefix(path, devDir+"/"), "/")+1 >= pf.MaxDepth {
+			return filepath.SkipDir
+		}
 		return nil
 	})
 
@@ -50,6 +82,16 @@ func (pf *ProjectFinder) FindProjects(devDir string) []string {
 	return projects
 }
 
+// skipped reports whether a directory name matches one of SkipDirs
+func (pf *ProjectFinder) skipped(name string) bool {
+	for _, pattern := range pf.SkipDirs {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // isGitRepo checks if a directory is a Git repository
 func isGitRepo(path string) bool {
 	_, err := os.Stat(filepath.Join(path, ".git"))
@@ -69,338 +111,714 @@ func RemoveDuplicates(items []string) []string {
 	return result
 }
 
-// MRUList manages the most recently used projects
-type MRUList struct {
-	filename string
-	baseDir  string
-	items    []string
-	dirty    bool
-	mu       sync.RWMutex
-	timer    *time.Timer
+// Config represents the application configuration
+type Config struct {
+	Selector       SelectorConfig          `yaml:"selector"`
+	Selectors      []SelectorConfig        `yaml:"selectors"` // Tried in order until one starts, overrides selector
+	Editor         EditorConfig            `yaml:"editor"`
+	Editors        map[string]EditorConfig `yaml:"editors"`      // Named editor profiles, picked with --editor or an editor:<name> action
+	EditorRules    []EditorRule            `yaml:"editor_rules"` // Editor profiles picked by project language or files
+	Format         FormatConfig            `yaml:"format"`
+	Remote         RemoteConfig            `yaml:"remote"`
+	Terminal       TerminalConfig          `yaml:"terminal"`
+	Display        DisplayConfig           `yaml:"display"`
+	Theme          ThemeConfig             `yaml:"theme"`
+	ProjectEnv     string                  `yaml:"project_env"`     // auto, direnv or nix: load the project environment
+	SessionNaming  string                  `yaml:"session_naming"`  // basename, parent or hash: name tmux sessions
+	Devcontainer   bool                    `yaml:"devcontainer"`    // Run terminal editors inside the project devcontainer
+	WindowCommands map[string][]string     `yaml:"window_commands"` // Window manager commands by project, run after those of the editor
+	Include        []string                `yaml:"include"`         // Files this one is merged over, relative to it
+}
+
+// SelectorConfig defines the project selector settings
+type SelectorConfig struct {
+	Command     string            `yaml:"command"`
+	Args        []string          `yaml:"args"`
+	PreviewArgs []string          `yaml:"preview_args"` // Added when previews are enabled, {{.Command}} is the preview command
+	ListenArgs  []string          `yaml:"listen_args"`  // Makes the selector accept fzf-style live updates on {{.Address}}
+	ExpectArgs  []string          `yaml:"expect_args"`  // Makes the selector print the accepting key first, {{.Keys}} lists the keys
+	ThemeArgs   []string          `yaml:"theme_args"`   // Added with a theme, {{.Foreground}}, {{.Accent}}, etc. are its colors
+	Actions     map[string]Action `yaml:"actions"`      // Key bindings, e.g. ctrl-d: forget
+	Cancel      CancelPolicy      `yaml:"cancel"`       // Outcomes meaning the user cancelled
+}
+
+// EditorConfig defines the editor launch settings
+type EditorConfig struct {
+	Command        string       `yaml:"command"`         // Without a command, args run inside the terminal
+	Args           string       `yaml:"args"`            // Template string
+	App            string       `yaml:"app"`             // app_id or class of a GUI editor, whose windows are matched by project name
+	Here           string       `yaml:"here"`            // Template string run in the current terminal by --here, defaults to args without a command
+	Layout         LayoutConfig `yaml:"layout"`          // Windows and panes of new tmux sessions
+	WindowCommands []string     `yaml:"window_commands"` // Window manager commands run on the window once focused or launched
+}
+
+// RemoteConfig defines how remote workspaces are opened in the terminal
+type RemoteConfig struct {
+	Args string `yaml:"args"` // Template string, the connect command is appended
+}
+
+// TerminalConfig defines how a terminal is opened in a project, either
+// plain or running an editor that has no command of its own
+type TerminalConfig struct {
+	Command string `yaml:"command"` // Defaults to the editor command
+	Args    string `yaml:"args"`    // Template string
+	Exec    string `yaml:"exec"`    // Placed before the program to run, e.g. "-e"
+}
+
+// DisplayConfig defines how much of the project list the selector gets
+type DisplayConfig struct {
+	MaxEntries int `yaml:"max_entries"` // Entries shown before "show all", 0 shows every project
+}
+
+// FormatConfig defines the formatting settings
+type FormatConfig struct {
+	ProjectTitle string            `yaml:"project_title"` // Template string
+	ExtractPath  string            `yaml:"extract_path"`  // Template string
+	PathStyle    string            `yaml:"path_style"`    // relative, full, basename, short or truncate-middle(N)
+	IconSet      string            `yaml:"icon_set"`      // emoji, nerd or ascii
+	Icons        map[string]string `yaml:"icons"`         // Icon overrides by language, "default" or "remote"
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Selector: SelectorConfig{
+			Command: "fuzzel",
+			Args:    []string{"--dmenu", "--prompt=Project: "},
+		},
+		Selectors: defaultSelectorChain(),
+		Editor: EditorConfig{
+			Command: "kitty",
+			Args:    "-d {{.Dir}} -T {{.Title}} --class {{.Title}} sh -c \"tmux new -c {{.Dir}} -A -s {{.Session}} nvim {{.Dir}} {{.ExtraArgs}}\"",
+			Here:    "tmux new -c {{.Dir}} -A -s {{.Session}} nvim {{.Dir}} {{.ExtraArgs}}",
+		},
+		Format: FormatConfig{
+			ProjectTitle: "📘 {{.Path}}",
+			ExtractPath:  "{{.Title | trimPrefix \"📘 \"}}",
+		},
+		Remote: RemoteConfig{
+			Args: "-T {{.Title}} --class {{.Title}}",
+		},
+		Terminal: TerminalConfig{
+			Args: "-d {{.Dir}} -T {{.Title}} --class {{.Title}}",
+		},
+	}
 }
 
-// NewMRUList creates a new MRU list
-func NewMRUList(filename, baseDir string) *MRUList {
-	mru := &MRUList{
-		filename: filename,
-		baseDir:  baseDir,
-		items:    loadMRU(filename),
-		dirty:    false,
+// LoadConfig loads configuration from a YAML file or returns default config.
+// Without a file, a launcher started from a terminal uses a terminal
+// selector instead of the graphical default.
+func LoadConfig(configFile string) (*Config, error) {
+	if configFile == "" {
+		config := DefaultConfig()
+		if interactiveTerminal() {
+			config.Selector = terminalSelector()
+			config.Selectors = nil
+		}
+		return config, nil
 	}
 
-	// Set up periodic save timer
-	mru.timer = time.AfterFunc(5*time.Second, func() {
-		mru.saveIfDirty()
-	})
+	if _, err := os.Stat(configFile); err != nil {
+		return LoadConfig("") // Return default if file doesn't exist
+	}
 
-	return mru
+	settings, _, err := ReadYAML(configFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
 }
 
-// loadMRU loads MRU items from file
-func loadMRU(filename string) []string {
-	data, err := os.ReadFile(filename)
+// Override merges settings over the config as if they were written at the
+// end of its file, e.g. {"terminal": "foot"}
+func (c *Config) Override(settings map[string]any) error {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	data, err := yaml.Marshal(settings)
 	if err != nil {
-		return []string{}
+		return fmt.Errorf("failed to encode overrides: %w", err)
 	}
-	return strings.Fields(string(data))
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to apply overrides: %w", err)
+	}
+	return c.validate()
 }
 
-// Update adds a project to the MRU list
-func (m *MRUList) Update(project string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// validate checks the settings that are not checked while parsing
+func (c *Config) validate() error {
+	if err := ValidatePathStyle(c.Format.PathStyle); err != nil {
+		return fmt.Errorf("invalid format.path_style: %w", err)
+	}
+
+	if err := ValidateIconSet(c.Format.IconSet); err != nil {
+		return fmt.Errorf("invalid format.icon_set: %w", err)
+	}
 
-	fullPath := filepath.Join(m.baseDir, project)
-	absPath, _ := filepath.Abs(fullPath)
-	m.items = updateMRUList(absPath, m.items)
-	m.dirty = true
+	if err := ValidateProjectEnv(c.ProjectEnv); err != nil {
+		return fmt.Errorf("invalid project_env: %w", err)
+	}
 
-	// Reset timer for immediate save
-	if m.timer != nil {
-		m.timer.Stop()
+	if err := ValidateLayout(c.Editor.Layout); err != nil {
+		return fmt.Errorf("invalid editor.layout: %w", err)
+	}
+	for name, editor := range c.Editors {
+		if err := ValidateLayout(editor.Layout); err != nil {
+			return fmt.Errorf("invalid editors.%s.layout: %w", name, err)
+		}
 	}
-	m.timer = time.AfterFunc(1*time.Second, func() {
-		m.saveIfDirty()
-	})
 
-	return nil
-}
+	if err := ValidateEditorRules(c.EditorRules, c.Editors); err != nil {
+		return fmt.Errorf("invalid editor_rules: %w", err)
+	}
 
-// Items returns the MRU items as relative paths
-func (m *MRUList) Items() []string {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	if err := ValidateSessionNaming(c.SessionNaming); err != nil {
+		return fmt.Errorf("invalid session_naming: %w", err)
+	}
+
+	if err := ValidateTheme(c.Theme); err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+
+	if c.Display.MaxEntries < 0 {
+		return fmt.Errorf("invalid display.max_entries: %d", c.Display.MaxEntries)
+	}
 
-	var relative []string
-	for _, item := range m.items {
-		rel, err := filepath.Rel(m.baseDir, item)
-		if err == nil {
-			relative = append(relative, rel)
+	for _, selector := range c.selectorChain() {
+		if err := ValidateActions(selector.Actions, c.Editors); err != nil {
+			return fmt.Errorf("invalid selector.actions: %w", err)
 		}
 	}
-	return relative
+
+	return nil
 }
 
-// Close ensures the MRU list is saved
-func (m *MRUList) Close() error {
-	if m.timer != nil {
-		m.timer.Stop()
-	}
-	return m.save()
+// Selector provides methods for project selection
+type Selector struct {
+	config           *Config
+	annotators       []Annotator
+	editorAnnotators []Annotator
+	lazyAnnotators   []lazyAnnotator
+	editorProfile    string
+	extraArgs        []string
+	launchLog        string
+	launchedPID      int
+	sessionSuffix    string
+	useContainer     bool
+	containerFolders map[string]string // Workspace folders of started devcontainers by project
+	annotationBudget time.Duration
+	previewCommand   string
+}
+
+// Annotator adds extra fields to the data passed to a template
+type Annotator func(project string, data map[string]string)
+
+// AddAnnotator registers a function that enriches project title data
+func (s *Selector) AddAnnotator(a Annotator) {
+	s.annotators = append(s.annotators, a)
+}
+
+// AddEditorAnnotator registers a function that enriches editor command data.
+// It receives the project directory being opened.
+func (s *Selector) AddEditorAnnotator(a Annotator) {
+	s.editorAnnotators = append(s.editorAnnotators, a)
+}
+
+// NewSelector creates a new selector instance
+func NewSelector(config *Config) *Selector {
+	return &Selector{config: config}
 }
 
-// save saves the MRU list to file
-func (m *MRUList) save() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Select runs the selector command and returns the selected projects and
+// the action bound to the key that accepted them. Selectors that support
+// multi-select may return more than one project; an empty result means
+// the user cancelled. Lists longer than display.max_entries are cut short,
+// with an entry that selects again over the whole list.
+func (s *Selector) Select(projects []string) (Action, []string, error) {
+	if len(projects) == 0 {
+		return "", nil, fmt.Errorf("no projects provided")
+	}
 
-	content := strings.Join(m.items, "\n")
-	err := os.WriteFile(m.filename, []byte(content), 0o644)
-	if err == nil {
-		m.dirty = false
+	limit := s.config.Display.MaxEntries
+	if limit == 0 || len(projects) <= limit {
+		return s.selectFrom(projects, "")
 	}
-	return err
+
+	showAll := fmt.Sprintf("%s show all %d projects", ellipsis, len(projects))
+	action, selected, err := s.selectFrom(projects[:limit], showAll)
+	if err != nil || !slices.Contains(selected, showAll) {
+		return action, selected, err
+	}
+	return s.selectFrom(projects, "")
 }
 
-// saveIfDirty saves only if the list has been modified
-func (m *MRUList) saveIfDirty() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// selectFrom runs the selector over projects, followed by the extra entry
+// when it is not empty. The extra entry is returned as is when selected.
+func (s *Selector) selectFrom(projects []string, extra string) (Action, []string, error) {
+	// Format projects using template, remembering which project each
+	// title came from so lossy path styles can still be resolved
+	scheduler := s.newAnnotationScheduler(projects)
+	formatted := make([]string, len(projects))
+	titles := newTitleIndex(len(projects))
+	for i, project := range projects {
+		data := s.projectData(project)
+		scheduler.placeholders(project, data)
+		formatted[i] = s.renderTitle(project, data)
+		titles.add(formatted[i], project)
+	}
+	if extra != "" {
+		formatted = append(formatted, extra)
+		titles.add(extra, extra)
+	}
+
+	action, results, err := s.run(formatted, scheduler, titles)
+	if err != nil {
+		return "", nil, err
+	}
 
-	if m.dirty {
-		content := strings.Join(m.items, "\n")
-		if err := os.WriteFile(m.filename, []byte(content), 0o644); err == nil {
-			m.dirty = false
+	selected := make([]string, 0, len(results))
+	for _, result := range results {
+		if project, ok := titles.lookup(result); ok {
+			selected = append(selected, project)
+			continue
 		}
+		// Extract path from formatted result
+		selected = append(selected, s.extractPath(result))
+	}
+
+	return action, RemoveDuplicates(selected), nil
+}
+
+// FormatTitles formats the selector entries of projects for selectors
+// driven outside of Select, such as rofi script mode. Lazy annotations are
+// resolved within the annotation budget.
+func (s *Selector) FormatTitles(projects []string) []string {
+	scheduler := s.newAnnotationScheduler(projects)
+	formatted := make([]string, len(projects))
+	titles := newTitleIndex(len(projects))
+	for i, project := range projects {
+		data := s.projectData(project)
+		scheduler.placeholders(project, data)
+		formatted[i] = s.renderTitle(project, data)
 	}
 
-	// Reset timer for next save
-	m.timer = time.AfterFunc(5*time.Second, func() {
-		m.saveIfDirty()
+	if scheduler != nil {
+		s.resolveWithinBudget(context.Background(), scheduler, formatted, titles)
+	}
+	return formatted
+}
+
+// resolveWithinBudget resolves lazy annotations into formatted, giving up
+// on the ones still running when the annotation budget runs out
+func (s *Selector) resolveWithinBudget(ctx context.Context, scheduler *annotationScheduler, formatted []string, titles *titleIndex) {
+	if s.annotationBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.annotationBudget)
+		defer cancel()
+	}
+	scheduler.run(ctx, func(index int, title string) {
+		formatted[index] = title
+		titles.add(title, scheduler.projects[index])
 	})
 }
 
-// updateMRUList updates the MRU list with a new project
-func updateMRUList(project string, list []string) []string {
-	newList := []string{project}
-	for _, p := range list {
-		if p != project && len(newList) < 10 {
-			newList = append(newList, p)
-		}
+// errSelectorUnavailable marks selectors that are missing or could not be
+// started, so the next selector of the chain is tried
+var errSelectorUnavailable = errors.New("selector unavailable")
+
+// selectorChain returns the selectors to try in order
+func (c *Config) selectorChain() []SelectorConfig {
+	if len(c.Selectors) > 0 {
+		return c.Selectors
 	}
-	return newList
+	return []SelectorConfig{c.Selector}
 }
 
-// WindowManager handles Sway window operations
-type WindowManager struct{}
+// run shows the formatted entries in the first selector of the chain that
+// starts and returns the chosen action and lines, or nothing if the user
+// cancelled
+func (s *Selector) run(formatted []string, scheduler *annotationScheduler, titles *titleIndex) (Action, []string, error) {
+	chain := s.config.selectorChain()
+	if chain[len(chain)-1].Command != NumberedSelector {
+		numbered, _ := SelectorPreset(NumberedSelector)
+		chain = append(chain, numbered)
+	}
 
-// FindWindow finds a window by title
-func (wm *WindowManager) FindWindow(title string) (int64, error) {
-	cmd := exec.Command("swaymsg", "-t", "get_tree")
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get sway tree: %w", err)
+	var errs []error
+	for _, selector := range chain {
+		key, results, err := s.runSelector(selector, formatted, scheduler, titles)
+		if errors.Is(err, errSelectorUnavailable) {
+			errs = append(errs, err)
+			continue
+		}
+		return selector.action(key), results, err
+	}
+	return "", nil, fmt.Errorf("no selector could be started: %w", errors.Join(errs...))
+}
+
+// runSelector shows the formatted entries in a selector and returns the
+// accepting key and the chosen lines, or nothing if the user cancelled.
+// Lazy annotations are resolved while the selector is open when it
+// supports live updates, and before it opens otherwise.
+func (s *Selector) runSelector(selector SelectorConfig, formatted []string, scheduler *annotationScheduler, titles *titleIndex) (string, []string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	command := selector.Command
+	if command == "" || command == BuiltinSelector {
+		var updates chan tui.Update
+		if scheduler != nil {
+			updates = make(chan tui.Update)
+			go func() {
+				defer close(updates)
+				scheduler.run(ctx, func(index int, title string) {
+					titles.add(title, scheduler.projects[index])
+					select {
+					case updates <- tui.Update{Index: index, Item: title}:
+					case <-ctx.Done():
+					}
+				})
+			}()
+		}
+		opts := tui.Options{
+			Updates: updates,
+			Expect:  selector.actionKeys(),
+		}
+		if s.config.Theme.active() {
+			opts.Accent, opts.Match = s.config.Theme.Accent, s.config.Theme.Match
+		}
+		result, err := tui.Run("Project: ", formatted, opts)
+		if errors.Is(err, tui.ErrNoTerminal) {
+			return "", nil, fmt.Errorf("%w: %s: %v", errSelectorUnavailable, BuiltinSelector, err)
+		}
+		return result.Key, result.Items, err
+	}
+
+	if command == NumberedSelector {
+		if !tui.IsTerminal(os.Stdin) {
+			return "", nil, fmt.Errorf("%w: %s: stdin is not a terminal", errSelectorUnavailable, NumberedSelector)
+		}
+		if scheduler != nil {
+			s.resolveWithinBudget(ctx, scheduler, formatted, titles)
+		}
+		result, err := tui.Numbered("Project ", formatted, os.Stdin, os.Stderr)
+		return result.Key, result.Items, err
 	}
 
-	var tree SwayTree
-	if err := json.Unmarshal(output, &tree); err != nil {
-		return 0, fmt.Errorf("failed to parse sway tree: %w", err)
+	if _, err := exec.LookPath(command); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errSelectorUnavailable, err)
 	}
 
-	// Search for window with matching title
-	for _, node := range tree.Nodes {
-		if windowID := findNodeByTitle(node, title); windowID != 0 {
-			return windowID, nil
+	var live *liveReload
+	var listenAddress string
+	if scheduler != nil && len(selector.ListenArgs) > 0 {
+		var err error
+		if live, err = newLiveReload(formatted); err != nil {
+			return "", nil, err
 		}
+		defer live.Close()
+		listenAddress = live.address
+	} else if scheduler != nil {
+		s.resolveWithinBudget(ctx, scheduler, formatted, titles)
 	}
 
-	return 0, nil
-}
+	args := s.selectorArgs(selector, listenAddress)
+	expectArgs := selector.expectArgs()
+
+	// Run selector command
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(formatted, "\n"))
+	cmd.Stderr = os.Stderr // Terminal selectors such as fzf draw their UI on stderr
+
+	if live != nil {
+		cmd.Env = append(os.Environ(), live.env()...)
+		go live.follow(ctx)
+		go scheduler.run(ctx, func(index int, title string) {
+			titles.add(title, scheduler.projects[index])
+			live.set(index, title)
+		})
+	}
 
-// FocusWindow focuses a window by ID
-func (wm *WindowManager) FocusWindow(windowID int64) error {
-	cmd := exec.Command("swaymsg", fmt.Sprintf(`[con_id="%d"] focus`, windowID))
 	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to focus window: %w", err)
+		exitError, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s: %v", errSelectorUnavailable, command, err)
+		}
+		// Check if it's a cancellation (exit code 1 for rofi/fuzzel)
+		if selector.Cancel.cancelledBy(exitError.ExitCode()) {
+			return "", nil, nil // User cancelled, return no selection
+		}
+		return "", nil, fmt.Errorf("command execution failed: %w", err)
 	}
 
-	// Check if the command succeeded
-	if strings.Contains(string(output), "success") {
-		return nil
+	// With expect args the accepting key comes first, empty for Enter
+	lines := strings.Split(string(output), "\n")
+	var key string
+	if len(expectArgs) > 0 {
+		key, lines = strings.TrimSpace(lines[0]), lines[1:]
 	}
 
-	return fmt.Errorf("swaymsg focus command failed: %s", string(output))
+	// Multi-select selectors print one entry per line
+	var results []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			results = append(results, line)
+		}
+	}
+	if len(results) == 0 {
+		if selector.Cancel.cancelledByEmptyOutput() {
+			return "", nil, nil // Some tools exit successfully on Escape
+		}
+		return "", nil, fmt.Errorf("no project selected")
+	}
+
+	return key, results, nil
 }
 
-// SwayNode represents a node in the Sway tree
-type SwayNode struct {
-	ID            int64      `json:"id"`
-	Name          string     `json:"name"`
-	AppID         *string    `json:"app_id"`
-	Nodes         []SwayNode `json:"nodes"`
-	FloatingNodes []SwayNode `json:"floating_nodes"`
+// selectorArgs returns the arguments of a selector command with the theme,
+// preview, live update and expect arguments that apply. Live update
+// arguments are only added with a listen address.
+func (s *Selector) selectorArgs(selector SelectorConfig, listenAddress string) []string {
+	args := append(append([]string(nil), selector.Args...), s.config.themeArgs(selector)...)
+	if s.previewCommand != "" {
+		args = append(args, renderSelectorArgs(selector.PreviewArgs, map[string]string{
+			"Command": s.previewCommand,
+		})...)
+	}
+	if listenAddress != "" {
+		args = append(args, renderSelectorArgs(selector.ListenArgs, map[string]string{
+			"Address": listenAddress,
+		})...)
+	}
+	return append(args, selector.expectArgs()...)
 }
 
-// SwayTree represents the root of the Sway tree
-type SwayTree struct {
-	Nodes []SwayNode `json:"nodes"`
+// SelectorCommands returns the command lines of the selectors that would
+// be tried, in order, as they would run without live updates
+func (s *Selector) SelectorCommands() []string {
+	var commands []string
+	for _, selector := range s.config.selectorChain() {
+		if selector.Command == "" || selector.Command == BuiltinSelector {
+			commands = append(commands, BuiltinSelector)
+			continue
+		}
+		commands = append(commands, ShellJoin(append([]string{selector.Command}, s.selectorArgs(selector, "")...)))
+	}
+	return commands
 }
 
-// findNodeByTitle recursively searches for a node with the given title
-func findNodeByTitle(node SwayNode, title string) int64 {
-	// Check if this node matches
-	if node.AppID != nil && node.Name == title {
-		return node.ID
+// EnablePreview makes selectors that support a preview pane show the
+// output of command, run with the highlighted entry as its last argument
+func (s *Selector) EnablePreview(command ...string) {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
 	}
+	s.previewCommand = strings.Join(quoted, " ")
+}
 
-	// Search in regular nodes
-	for _, n := range node.Nodes {
-		if windowID := findNodeByTitle(n, title); windowID != 0 {
-			return windowID
+// renderSelectorArgs renders extra selector argument templates with data,
+// skipping arguments that fail to render
+func renderSelectorArgs(templates []string, data map[string]string) []string {
+	args := make([]string, 0, len(templates))
+	for _, arg := range templates {
+		tmpl, err := template.New("selector").Parse(arg)
+		if err != nil {
+			continue
+		}
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, data); err != nil {
+			continue
 		}
+		args = append(args, buf.String())
 	}
+	return args
+}
+
+// ExtractPath resolves a formatted selector entry back to its project path
+func (s *Selector) ExtractPath(title string) string {
+	return s.extractPath(title)
+}
+
+// UseBuiltin switches the selector to the built-in terminal finder
+func (s *Selector) UseBuiltin() {
+	s.config.Selector, _ = SelectorPreset(BuiltinSelector)
+	s.config.Selectors = nil
+}
 
-	// Search in floating nodes
-	for _, n := range node.FloatingNodes {
-		if windowID := findNodeByTitle(n, title); windowID != 0 {
-			return windowID
+// UseEditor makes the selector open projects with a named editor profile,
+// or else the editor preset of that name
+func (s *Selector) UseEditor(name string) error {
+	editor, ok := s.config.Editors[name]
+	if !ok {
+		editor, ok = editorPresets[name]
+	}
+	if !ok {
+		names := make([]string, 0, len(s.config.Editors))
+		for profile := range s.config.Editors {
+			names = append(names, profile)
+		}
+		slices.Sort(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown editor %q: no editors defined (presets: %s)", name, strings.Join(EditorPresetNames(), ", "))
 		}
+		return fmt.Errorf("unknown editor %q (profiles: %s; presets: %s)", name, strings.Join(names, ", "), strings.Join(EditorPresetNames(), ", "))
 	}
+	s.config.Editor = editor
+	s.editorProfile = name
+	return nil
+}
 
-	return 0
+// WindowCommands returns the window manager commands run on the window of
+// a project once it is focused or launched: those of the editor, then those
+// of the project
+func (s *Selector) WindowCommands(project string) []string {
+	return append(slices.Clone(s.config.Editor.WindowCommands), s.config.WindowCommands[project]...)
 }
 
-// Config represents the application configuration
-type Config struct {
-	Selector SelectorConfig `yaml:"selector"`
-	Editor   EditorConfig   `yaml:"editor"`
-	Format   FormatConfig   `yaml:"format"`
+// SetExtraArgs passes extra arguments to the editor, as {{.ExtraArgs}} in
+// the editor template or else at the end of the command
+func (s *Selector) SetExtraArgs(args []string) {
+	s.extraArgs = args
 }
 
-// SelectorConfig defines the project selector settings
-type SelectorConfig struct {
-	Command string   `yaml:"command"`
-	Args    []string `yaml:"args"`
+// EditorProfile returns the editor profile in use, empty for the default
+// editor
+func (s *Selector) EditorProfile() string {
+	return s.editorProfile
 }
 
-// EditorConfig defines the editor launch settings
-type EditorConfig struct {
-	Command string `yaml:"command"`
-	Args    string `yaml:"args"` // Template string
+// EditorApp returns the application of a GUI editor, empty for editors
+// whose windows carry the title they were started with
+func (s *Selector) EditorApp() string {
+	return s.config.Editor.App
 }
 
-// FormatConfig defines the formatting settings
-type FormatConfig struct {
-	ProjectTitle string `yaml:"project_title"` // Template string
-	ExtractPath  string `yaml:"extract_path"`  // Template string
+// EditorCommand returns the command line that launches the editor for the
+// given project
+func (s *Selector) EditorCommand(dir, title string) []string {
+	if s.inContainer(dir) {
+		if argv, err := s.containerCommand(dir, title); err == nil {
+			return argv
+		}
+	}
+
+	editorCmd, editorArgs := s.buildEditorCommand(dir, title)
+	editorCmd, editorArgs = s.wrapProjectEnv(dir, editorCmd, editorArgs)
+	return append([]string{editorCmd}, editorArgs...)
 }
 
-// DefaultConfig returns the default configuration
-func DefaultConfig() *Config {
-	return &Config{
-		Selector: SelectorConfig{
-			Command: "fuzzel",
-			Args:    []string{"--dmenu", "--prompt=Project: "},
-		},
-		Editor: EditorConfig{
-			Command: "kitty",
-			Args:    "-d {{.Dir}} -T {{.Title}} --class {{.Title}} sh -c \"tmux new -c {{.Dir}} -A -s {{.Name}} nvim {{.Dir}}\"",
-		},
-		Format: FormatConfig{
-			ProjectTitle: "📘 {{.Path}}",
-			ExtractPath:  "{{.Title | trimPrefix \"📘 \"}}",
-		},
+// Start launches the editor for the given project
+func (s *Selector) Start(dir, title string) error {
+	if err := s.StartContainer(dir); err != nil {
+		return err
+	}
+	if err := s.PrepareLayout(dir, title); err != nil {
+		return err
 	}
+	return s.launch(s.EditorCommand(dir, title))
 }
 
-// LoadConfig loads configuration from a YAML file or returns default config
-func LoadConfig(configFile string) (*Config, error) {
-	if configFile == "" {
-		return DefaultConfig(), nil
+// StartRemote opens a terminal running the connect command of a remote workspace
+func (s *Selector) StartRemote(title string, connect []string) error {
+	argsTemplate := s.config.Remote.Args
+	if argsTemplate == "" {
+		argsTemplate = DefaultConfig().Remote.Args
 	}
 
-	data, err := os.ReadFile(configFile)
+	data := map[string]string{
+		"Title": sanitizeTitle(title),
+	}
+	args, err := renderArgs("remote", argsTemplate, nil, data, nil)
 	if err != nil {
-		return DefaultConfig(), nil // Return default if file doesn't exist
+		return err
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	command := s.config.Editor.Command
+	if s.config.Terminal.Command != "" {
+		home, _ := os.UserHomeDir()
+		if command, args, err = s.terminalCommand(home, title, connect); err != nil {
+			return err
+		}
+	} else {
+		args = append(args, connect...)
 	}
-
-	return &config, nil
-}
-
-// Selector provides methods for project selection
-type Selector struct {
-	config *Config
+	return s.launch(append([]string{command}, args...))
 }
 
-// NewSelector creates a new selector instance
-func NewSelector(config *Config) *Selector {
-	return &Selector{config: config}
+// StartTerminal opens a plain terminal in the given project directory
+func (s *Selector) StartTerminal(dir, title string) error {
+	command, args, err := s.terminalCommand(dir, title, nil)
+	if err != nil {
+		return err
+	}
+	command, args = s.wrapProjectEnv(dir, command, args)
+	return s.launch(append([]string{command}, args...))
 }
 
-// Select runs the selector command and returns the selected project
-func (s *Selector) Select(projects []string) (string, error) {
-	if len(projects) == 0 {
-		return "", fmt.Errorf("no projects provided")
+// terminalCommand builds the command opening a terminal in dir, running
+// program when given
+func (s *Selector) terminalCommand(dir, title string, program []string) (string, []string, error) {
+	terminal := s.config.Terminal
+	if terminal.Command == "" {
+		terminal.Command = s.config.Editor.Command // The default args are kitty's
 	}
-
-	// Format projects using template
-	formatted := make([]string, len(projects))
-	for i, project := range projects {
-		formatted[i] = s.formatProjectTitle(project)
+	if terminal.Command == "" {
+		return "", nil, fmt.Errorf("no terminal command configured")
+	}
+	if terminal.Args == "" {
+		terminal.Args = DefaultConfig().Terminal.Args
 	}
 
-	// Run selector command
-	cmd := exec.Command(s.config.Selector.Command, s.config.Selector.Args...)
-	cmd.Stdin = strings.NewReader(strings.Join(formatted, "\n"))
-
-	output, err := cmd.Output()
+	data := map[string]string{
+		"Dir":   dir,
+		"Title": sanitizeTitle(title),
+		"Name":  filepath.Base(dir),
+	}
+	args, err := renderArgs("terminal", terminal.Args, nil, data, nil)
 	if err != nil {
-		// Check if it's a cancellation (exit code 1 for rofi/fuzzel)
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return "", nil // User cancelled, return empty string
-		}
-		return "", fmt.Errorf("command execution failed: %w", err)
+		return "", nil, err
 	}
 
-	result := strings.TrimSpace(string(output))
-	if result == "" {
-		return "", fmt.Errorf("no project selected")
+	if len(program) > 0 {
+		args = append(append(args, strings.Fields(terminal.Exec)...), program...)
 	}
-
-	// Extract path from formatted result
-	return s.extractPath(result), nil
+	return terminal.Command, args, nil
 }
 
-// Start launches the editor for the given project
-func (s *Selector) Start(dir, title string) error {
-	editorCmd, editorArgs := s.buildEditorCommand(dir, title)
-
-	cmd := exec.Command(editorCmd, editorArgs...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Start()
+// projectData returns the project title data set by the annotators
+func (s *Selector) projectData(path string) map[string]string {
+	data := map[string]string{
+		"Path": path,
+	}
+	for _, annotate := range s.annotators {
+		annotate(path, data)
+	}
+	return data
 }
 
-// formatProjectTitle formats a project path using the template
-func (s *Selector) formatProjectTitle(path string) string {
-	tmpl, err := template.New("project").Parse(s.config.Format.ProjectTitle)
+// renderTitle formats a project path using the template
+func (s *Selector) renderTitle(path string, data map[string]string) string {
+	tmpl, err := template.New("project").Funcs(formatFuncs()).Parse(s.config.Format.ProjectTitle)
 	if err != nil {
 		return path // Fallback to original path
 	}
 
 	var buf strings.Builder
-	data := map[string]string{
-		"Path": path,
-	}
-
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return path // Fallback to original path
 	}
@@ -408,11 +826,51 @@ func (s *Selector) formatProjectTitle(path string) string {
 	return buf.String()
 }
 
+// formatFuncs returns the helpers available to the format templates.
+// The string being transformed is always the last argument so helpers
+// can be chained with pipes.
+func formatFuncs() template.FuncMap {
+	return template.FuncMap{
+		"trimPrefix": func(prefix, s string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		"trimSuffix": func(suffix, s string) string {
+			return strings.TrimSuffix(s, suffix)
+		},
+		"before": func(sep, s string) string {
+			before, _, _ := strings.Cut(s, sep)
+			return before
+		},
+		"padRight": padRight,
+		"padLeft":  padLeft,
+		"truncate": truncate,
+	}
+}
+
+// HumanizeSince renders the time elapsed since t as "5m ago", "2h ago", "3d ago"
+func HumanizeSince(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	elapsed := time.Since(t)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	case elapsed < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	default:
+		return fmt.Sprintf("%dmo ago", int(elapsed.Hours()/24/30))
+	}
+}
+
 // extractPath extracts the project path from formatted title
 func (s *Selector) extractPath(title string) string {
-	tmpl, err := template.New("extract").Funcs(template.FuncMap{
-		"trimPrefix": strings.TrimPrefix,
-	}).Parse(s.config.Format.ExtractPath)
+	tmpl, err := template.New("extract").Funcs(formatFuncs()).Parse(s.config.Format.ExtractPath)
 	if err != nil {
 		return title // Fallback to original title
 	}
@@ -429,36 +887,93 @@ func (s *Selector) extractPath(title string) string {
 	return strings.TrimSpace(buf.String())
 }
 
-// buildEditorCommand builds the editor command and arguments
-func (s *Selector) buildEditorCommand(dir, title string) (string, []string) {
-	tmpl, err := template.New("editor").Funcs(template.FuncMap{
-		"sanitize": sanitizeForTmux,
-	}).Parse(s.config.Editor.Args)
-	if err != nil {
-		// Fallback to simple command
-		return s.config.Editor.Command, []string{"-d", dir, "-T", title, "--class", title}
-	}
-
-	var buf strings.Builder
+// editorData returns the data of the editor templates for a project
+func (s *Selector) editorData(dir, title string) map[string]string {
 	data := map[string]string{
 		"Dir":           dir,
 		"Title":         title,
 		"Name":          filepath.Base(dir),
-		"SanitizedName": sanitizeForTmux(filepath.Base(dir)),
+		"SanitizedName": SanitizeForTmux(filepath.Base(dir)),
+		"Session":       SessionNames(s.config.SessionNaming, dir)[0],
 	}
+	for _, annotate := range s.editorAnnotators {
+		annotate(dir, data)
+	}
+	data["Session"] += s.sessionSuffix
+	return data
+}
 
-	if err := tmpl.Execute(&buf, data); err != nil {
+// renderEditorArgs renders an editor template, with the extra arguments
+// at the end when the template does not place them
+func (s *Selector) renderEditorArgs(name, src string, data map[string]string) ([]string, error) {
+	args, err := renderArgs(name, src, template.FuncMap{
+		"sanitize": SanitizeForTmux,
+		"env":      os.Getenv,
+	}, data, map[string][]string{
+		"ExtraArgs": s.extraArgs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(src, ".ExtraArgs") {
+		args = append(args, s.extraArgs...)
+	}
+	return args, nil
+}
+
+// buildEditorCommand builds the editor command and arguments
+func (s *Selector) buildEditorCommand(dir, title string) (string, []string) {
+	title = sanitizeTitle(title)
+
+	args, err := s.renderEditorArgs("editor", s.config.Editor.Args, s.editorData(dir, title))
+	if err != nil {
 		// Fallback to simple command
 		return s.config.Editor.Command, []string{"-d", dir, "-T", title, "--class", title}
 	}
 
-	// Parse the template result into command and arguments
-	args := strings.Fields(buf.String())
+	if s.config.Editor.Command == "" {
+		// The args are the program to run inside the terminal
+		if command, terminalArgs, err := s.terminalCommand(dir, title, args); err == nil {
+			return command, terminalArgs
+		}
+	}
+
 	return s.config.Editor.Command, args
 }
 
-// sanitizeForTmux sanitizes a string for use as a tmux session name
-func sanitizeForTmux(name string) string {
+// HereCommand returns the command line that runs the editor in the
+// current terminal instead of a new window
+func (s *Selector) HereCommand(dir, title string) ([]string, error) {
+	if s.inContainer(dir) {
+		return s.containerProgram(dir, title)
+	}
+	program, err := s.terminalProgram(dir, s.editorData(dir, sanitizeTitle(title)))
+	if err != nil {
+		return nil, err
+	}
+	command, args := s.wrapProjectEnv(dir, program[0], program[1:])
+	return append([]string{command}, args...), nil
+}
+
+// terminalProgram renders the program the editor runs inside a terminal:
+// editor.here, or the args of editors without a command
+func (s *Selector) terminalProgram(dir string, data map[string]string) ([]string, error) {
+	editor := s.config.Editor
+	src := editor.Here
+	if src == "" {
+		if editor.Command != "" {
+			return nil, fmt.Errorf("editor %s cannot run in the current terminal, set editor.here", editor.Command)
+		}
+		src = editor.Args // Already the program the terminal runs
+	}
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("no editor configured")
+	}
+	return s.renderEditorArgs("here", src, data)
+}
+
+// SanitizeForTmux sanitizes a string for use as a tmux session name
+func SanitizeForTmux(name string) string {
 	// Replace any non-alphanumeric characters with underscores
 	result := ""
 	for _, r := range name {
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Path styles accepted by format.path_style
+const (
+	PathStyleRelative       = "relative"
+	PathStyleFull           = "full"
+	PathStyleBasename       = "basename"
+	PathStyleShort          = "short"
+	PathStyleTruncateMiddle = "truncate-middle"
+
+	defaultTruncateWidth = 40
+	ellipsis             = "…"
+)
+
+// StylePath renders a project path relative to baseDir in the given style:
+// relative (default), full, basename, short or truncate-middle(N). Without
+// the other projects to compare with, short shows the basename.
+func StylePath(style, baseDir, path string) string {
+	name, width, err := parsePathStyle(style)
+	if err != nil {
+		return path
+	}
+
+	switch name {
+	case PathStyleFull:
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	case PathStyleBasename, PathStyleShort:
+		return filepath.Base(path)
+	case PathStyleTruncateMiddle:
+		return truncateMiddle(path, width)
+	default:
+		return path
+	}
+}
+
+// PathStyler renders the paths of a project list in a path style. The short
+// style needs the whole list to add parent directories where names collide.
+type PathStyler struct {
+	style   string
+	baseDir string
+	short   map[string]string // Project to short path, only for the short style
+}
+
+// NewPathStyler returns a styler for the paths of projects
+func NewPathStyler(style, baseDir string, projects []string) *PathStyler {
+	p := &PathStyler{style: style, baseDir: baseDir}
+	if name, _, _ := parsePathStyle(style); name == PathStyleShort {
+		p.short = ShortPaths(projects)
+	}
+	return p
+}
+
+// Style renders a project path
+func (p *PathStyler) Style(path string) string {
+	if short, ok := p.short[path]; ok {
+		return short
+	}
+	return StylePath(p.style, p.baseDir, path)
+}
+
+// Resolve maps a styled path back to its project, returning it unchanged
+// when it is not a short path of the list
+func (p *PathStyler) Resolve(styled string) string {
+	for project, short := range p.short {
+		if short == styled {
+			return project
+		}
+	}
+	return styled
+}
+
+// ShortPaths maps every path to its basename, extended with parent
+// directories until it no longer collides with another path of the list
+func ShortPaths(paths []string) map[string]string {
+	depth := make(map[string]int, len(paths))
+	for _, path := range paths {
+		depth[path] = 1
+	}
+
+	short := make(map[string]string, len(paths))
+	for {
+		owners := make(map[string][]string, len(paths))
+		for _, path := range paths {
+			short[path] = lastElements(path, depth[path])
+			owners[short[path]] = append(owners[short[path]], path)
+		}
+
+		grown := false
+		for _, group := range owners {
+			if len(group) < 2 {
+				continue
+			}
+			for _, path := range group {
+				if lastElements(path, depth[path]+1) != short[path] {
+					depth[path]++
+					grown = true
+				}
+			}
+		}
+		if !grown {
+			return short
+		}
+	}
+}
+
+// lastElements returns the last n elements of a slash-separated path
+func lastElements(path string, n int) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if n >= len(parts) {
+		return strings.Join(parts, "/")
+	}
+	return strings.Join(parts[len(parts)-n:], "/")
+}
+
+// ValidatePathStyle reports whether style is a known path style
+func ValidatePathStyle(style string) error {
+	_, _, err := parsePathStyle(style)
+	return err
+}
+
+// parsePathStyle splits a style such as "truncate-middle(40)" into its
+// name and width argument
+func parsePathStyle(style string) (string, int, error) {
+	style = strings.TrimSpace(style)
+	if style == "" {
+		return PathStyleRelative, 0, nil
+	}
+
+	name, arg, hasArg := strings.Cut(style, "(")
+	switch name {
+	case PathStyleRelative, PathStyleFull, PathStyleBasename, PathStyleShort:
+		if hasArg {
+			return "", 0, fmt.Errorf("path style %q takes no argument", name)
+		}
+		return name, 0, nil
+	case PathStyleTruncateMiddle:
+		if !hasArg {
+			return name, defaultTruncateWidth, nil
+		}
+		width, err := strconv.Atoi(strings.TrimSuffix(arg, ")"))
+		if err != nil || !strings.HasSuffix(arg, ")") || width < 3 {
+			return "", 0, fmt.Errorf("invalid width in path style %q", style)
+		}
+		return name, width, nil
+	default:
+		return "", 0, fmt.Errorf("unknown path style %q", style)
+	}
+}
+
+// truncateMiddle shortens s to at most width runes by replacing its middle
+// with an ellipsis, keeping both the top-level directory and the project name
+func truncateMiddle(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	keep := width - 1 // Room for the ellipsis
+	head := keep / 2
+	tail := keep - head
+	return string(runes[:head]) + ellipsis + string(runes[len(runes)-tail:])
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Project environment loaders accepted by project_env
+const (
+	ProjectEnvAuto   = "auto"
+	ProjectEnvDirenv = "direnv"
+	ProjectEnvNix    = "nix"
+)
+
+// ValidateProjectEnv reports whether env is a known environment loader
+func ValidateProjectEnv(env string) error {
+	switch env {
+	case "", ProjectEnvAuto, ProjectEnvDirenv, ProjectEnvNix:
+		return nil
+	default:
+		return fmt.Errorf("unknown project environment %q", env)
+	}
+}
+
+// wrapProjectEnv runs a command through the environment loader of the
+// project in dir: `direnv exec` when it has an .envrc, `nix develop` when it
+// has a flake.nix. Commands are left alone when the project has neither or
+// the loader is not installed.
+func (s *Selector) wrapProjectEnv(dir, command string, args []string) (string, []string) {
+	env := s.config.ProjectEnv
+	useDirenv := (env == ProjectEnvAuto || env == ProjectEnvDirenv) && canLoadEnv(dir, ".envrc", "direnv")
+	useNix := (env == ProjectEnvAuto || env == ProjectEnvNix) && canLoadEnv(dir, "flake.nix", "nix")
+
+	switch {
+	case useDirenv:
+		return "direnv", append([]string{"exec", dir, command}, args...)
+	case useNix:
+		return "nix", append([]string{"develop", dir, "--command", command}, args...)
+	default:
+		return command, args
+	}
+}
+
+// canLoadEnv reports whether dir has the given file and tool is installed
+func canLoadEnv(dir, file, tool string) bool {
+	if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+		return false
+	}
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+// ProjectCommand returns argv run through the environment loader of the
+// project in dir, as editors are launched
+func (s *Selector) ProjectCommand(dir string, argv []string) []string {
+	command, args := s.wrapProjectEnv(dir, argv[0], argv[1:])
+	return append([]string{command}, args...)
+}
@@ -0,0 +1,127 @@
+package core
+
+import "fmt"
+
+// Icon sets accepted by format.icon_set
+const (
+	IconSetEmoji = "emoji"
+	IconSetNerd  = "nerd"
+	IconSetASCII = "ascii"
+)
+
+// Icon keys for projects without a detected language
+const (
+	IconDefault = "default"
+	IconRemote  = "remote"
+)
+
+// iconSets map languages from DetectLanguage to their icons
+var iconSets = map[string]map[string]string{
+	IconSetEmoji: {
+		"Go":         "🐹",
+		"Rust":       "🦀",
+		"TypeScript": "🔷",
+		"JavaScript": "🟨",
+		"Python":     "🐍",
+		"Java":       "☕",
+		"Kotlin":     "🟪",
+		"Ruby":       "💎",
+		"Elixir":     "💧",
+		"PHP":        "🐘",
+		"C#":         "🟩",
+		"Swift":      "🐦",
+		"Dart":       "🎯",
+		"Zig":        "⚡",
+		"Haskell":    "🎩",
+		"Clojure":    "🌀",
+		"OCaml":      "🐫",
+		"C/C++":      "🔧",
+		"Nix":        "🧊",
+		"Lua":        "🌙",
+		IconDefault:  "📁",
+		IconRemote:   "🌐",
+	},
+	IconSetNerd: {
+		"Go":         "\ue627",
+		"Rust":       "\ue7a8",
+		"TypeScript": "\ue628",
+		"JavaScript": "\ue74e",
+		"Python":     "\ue73c",
+		"Java":       "\ue738",
+		"Kotlin":     "\ue634",
+		"Ruby":       "\ue739",
+		"Elixir":     "\ue62d",
+		"PHP":        "\ue73d",
+		"C#":         "\ue648",
+		"Swift":      "\ue755",
+		"Dart":       "\ue798",
+		"Zig":        "\ue6a9",
+		"Haskell":    "\ue777",
+		"Clojure":    "\ue768",
+		"OCaml":      "\ue67a",
+		"C/C++":      "\ue61d",
+		"Nix":        "\uf313",
+		"Lua":        "\ue620",
+		IconDefault:  "\uf07b",
+		IconRemote:   "\uf0c2",
+	},
+	IconSetASCII: {
+		"Go":         "[go]",
+		"Rust":       "[rs]",
+		"TypeScript": "[ts]",
+		"JavaScript": "[js]",
+		"Python":     "[py]",
+		"Java":       "[java]",
+		"Kotlin":     "[kt]",
+		"Ruby":       "[rb]",
+		"Elixir":     "[ex]",
+		"PHP":        "[php]",
+		"C#":         "[cs]",
+		"Swift":      "[swift]",
+		"Dart":       "[dart]",
+		"Zig":        "[zig]",
+		"Haskell":    "[hs]",
+		"Clojure":    "[clj]",
+		"OCaml":      "[ml]",
+		"C/C++":      "[c]",
+		"Nix":        "[nix]",
+		"Lua":        "[lua]",
+		IconDefault:  "[-]",
+		IconRemote:   "[@]",
+	},
+}
+
+// LanguageIcon returns the icon for a language or icon key. Entries of
+// overrides win over the icon set; unknown languages get the default icon.
+func LanguageIcon(set string, overrides map[string]string, language string) string {
+	if language == "" {
+		language = IconDefault
+	}
+	if icon, ok := overrides[language]; ok {
+		return icon
+	}
+
+	icons, ok := iconSets[set]
+	if !ok {
+		icons = iconSets[IconSetEmoji]
+	}
+	if icon, ok := icons[language]; ok {
+		return icon
+	}
+
+	if icon, ok := overrides[IconDefault]; ok {
+		return icon
+	}
+	return icons[IconDefault]
+}
+
+// ValidateIconSet reports whether set is a known icon set
+func ValidateIconSet(set string) error {
+	if set == "" {
+		return nil
+	}
+	if _, ok := iconSets[set]; !ok {
+		return fmt.Errorf("unknown icon set %q", set)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey lists the files a config file is merged over
+const includeKey = "include"
+
+// ReadYAML reads a YAML file with the files it includes merged under it,
+// returning its settings and the included files in the order read
+func ReadYAML(path string) (map[string]any, []string, error) {
+	return readYAML(path, map[string]bool{})
+}
+
+// ReadIncludes reads the files of an include list of the file from, each
+// merged over the ones before it
+func ReadIncludes(from string, include []string) (map[string]any, []string, error) {
+	return readIncludes(from, include, map[string]bool{filepath.Clean(from): true})
+}
+
+// readYAML reads a file and its includes, seen holding the files being
+// read to catch include cycles
+func readYAML(path string, seen map[string]bool) (map[string]any, []string, error) {
+	path = filepath.Clean(path)
+	if seen[path] {
+		return nil, nil, fmt.Errorf("%s includes itself", path)
+	}
+	seen[path] = true
+	defer delete(seen, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+
+	var include []string
+	switch value := settings[includeKey].(type) {
+	case nil:
+		return settings, nil, nil
+	case string:
+		include = []string{value}
+	case []any:
+		for _, item := range value {
+			file, ok := item.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: include takes file names", path)
+			}
+			include = append(include, file)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%s: include takes file names", path)
+	}
+
+	merged, files, err := readIncludes(path, include, seen)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergeSettings(merged, settings)
+	return merged, files, nil
+}
+
+// readIncludes reads the included files in order, later ones merged over
+// earlier ones
+func readIncludes(from string, include []string, seen map[string]bool) (map[string]any, []string, error) {
+	merged := map[string]any{}
+	var files []string
+	for _, file := range include {
+		path, err := includePath(from, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		settings, included, err := readYAML(path, seen)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to include %s: %w", file, err)
+		}
+		files = append(files, path)
+		files = append(files, included...)
+		mergeSettings(merged, settings)
+	}
+	return merged, files, nil
+}
+
+// includePath resolves an included file name, relative to the directory of
+// the including file unless absolute or under ~/
+func includePath(from, file string) (string, error) {
+	if rest, ok := strings.CutPrefix(file, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	return filepath.Join(filepath.Dir(from), file), nil
+}
+
+// mergeSettings merges src over dst: maps are merged key by key, anything
+// else in src replaces what dst has
+func mergeSettings(dst, src map[string]any) {
+	for key, value := range src {
+		if key == includeKey {
+			continue
+		}
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeSettings(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
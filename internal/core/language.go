@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// languageIndicators maps files found at a project root to its language,
+// in priority order so e.g. TypeScript wins over JavaScript
+var languageIndicators = []struct {
+	pattern  string
+	language string
+}{
+	{"go.mod", "Go"},
+	{"Cargo.toml", "Rust"},
+	{"tsconfig.json", "TypeScript"},
+	{"package.json", "JavaScript"},
+	{"pyproject.toml", "Python"},
+	{"setup.py", "Python"},
+	{"requirements.txt", "Python"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"build.gradle.kts", "Kotlin"},
+	{"Gemfile", "Ruby"},
+	{"mix.exs", "Elixir"},
+	{"composer.json", "PHP"},
+	{"*.csproj", "C#"},
+	{"*.sln", "C#"},
+	{"Package.swift", "Swift"},
+	{"pubspec.yaml", "Dart"},
+	{"build.zig", "Zig"},
+	{"stack.yaml", "Haskell"},
+	{"*.cabal", "Haskell"},
+	{"deps.edn", "Clojure"},
+	{"project.clj", "Clojure"},
+	{"dune-project", "OCaml"},
+	{"CMakeLists.txt", "C/C++"},
+	{"meson.build", "C/C++"},
+	{"flake.nix", "Nix"},
+	{"init.lua", "Lua"},
+}
+
+// DetectLanguage guesses the main language of a project from the files at
+// its root. It returns an empty string when nothing matches.
+func DetectLanguage(dir string) string {
+	for _, indicator := range languageIndicators {
+		if hasRootFile(dir, indicator.pattern) {
+			return indicator.language
+		}
+	}
+	return ""
+}
+
+// hasRootFile checks if a file matching pattern exists directly in dir
+func hasRootFile(dir, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil || len(matches) == 0 {
+		return false
+	}
+	_, err = os.Stat(matches[0])
+	return err == nil
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	launchWatchTime = time.Second // How long launched commands are watched for failures
+	maxLaunchLog    = 1 << 20     // The launch log starts over past this size
+	maxFailureLog   = 2048        // Output of a failed launch included in its error
+)
+
+// DefaultLaunchLog returns the launch log location under the XDG state dir
+func DefaultLaunchLog() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "code", "launch.log")
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "code", "launch.log")
+}
+
+// SetLaunchLog sets the file the output of launched commands goes to.
+// Without one, their output is discarded.
+func (s *Selector) SetLaunchLog(path string) {
+	s.launchLog = path
+}
+
+// LaunchedPID returns the process of the last launched command when it kept
+// running, 0 when it handed over to an instance already running
+func (s *Selector) LaunchedPID() int {
+	return s.launchedPID
+}
+
+// Launch starts a command such as a remote editor the way editors are
+// started, see launch
+func (s *Selector) Launch(argv []string) error {
+	return s.launch(argv)
+}
+
+// launch starts argv detached from the launcher, in a session of its own
+// with its output appended to the launch log, so it survives the launcher
+// exiting. Commands failing within launchWatchTime are reported with
+// their output.
+func (s *Selector) launch(argv []string) error {
+	s.launchedPID = 0
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	logFile, offset := s.openLaunchLog(argv)
+	if logFile != nil {
+		defer logFile.Close()
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err := <-exited:
+		if err == nil {
+			return nil // Handed over to a running instance
+		}
+		if output := readLaunchOutput(s.launchLog, offset); output != "" {
+			return fmt.Errorf("%s failed: %w\n%s", filepath.Base(argv[0]), err, output)
+		}
+		return fmt.Errorf("%s failed: %w", filepath.Base(argv[0]), err)
+	case <-time.After(launchWatchTime):
+		s.launchedPID = cmd.Process.Pid
+		return nil
+	}
+}
+
+// openLaunchLog opens the launch log for appending and writes a header for
+// argv, returning the offset the output starts at. It returns nil when
+// there is no usable log.
+func (s *Selector) openLaunchLog(argv []string) (*os.File, int64) {
+	if s.launchLog == "" {
+		return nil, 0
+	}
+	if err := os.MkdirAll(filepath.Dir(s.launchLog), 0755); err != nil {
+		return nil, 0
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if info, err := os.Stat(s.launchLog); err == nil && info.Size() > maxLaunchLog {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(s.launchLog, flags, 0600)
+	if err != nil {
+		return nil, 0
+	}
+
+	fmt.Fprintf(file, "==> %s %s\n", time.Now().Format(time.RFC3339), ShellJoin(argv))
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		offset = 0
+	}
+	return file, offset
+}
+
+// readLaunchOutput returns the end of what a launch wrote to the log
+// from offset on
+func readLaunchOutput(path string, offset int64) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size()-offset > maxFailureLog {
+		offset = info.Size() - maxFailureLog
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return ""
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectLayoutFile is the file in a project root that overrides the
+// layout of the editor
+const ProjectLayoutFile = ".code-layout.yaml"
+
+// Pane splits accepted by layout windows
+const (
+	SplitHorizontal = "horizontal" // Panes side by side
+	SplitVertical   = "vertical"   // Panes stacked
+)
+
+// LayoutConfig describes the tmux windows a new project session starts
+// with. The editor template attaches to the session once it is set up.
+type LayoutConfig struct {
+	Windows []LayoutWindow `yaml:"windows"`
+}
+
+// LayoutWindow is a tmux window of a session layout
+type LayoutWindow struct {
+	Name   string   `yaml:"name"`
+	Panes  []string `yaml:"panes"`  // Template strings run in the panes, empty for a shell
+	Split  string   `yaml:"split"`  // horizontal (default) or vertical
+	Layout string   `yaml:"layout"` // tmux layout applied once the panes exist, e.g. main-vertical
+}
+
+// ValidateLayout checks the splits of a layout
+func ValidateLayout(layout LayoutConfig) error {
+	for i, w := range layout.Windows {
+		switch w.Split {
+		case "", SplitHorizontal, SplitVertical:
+		default:
+			return fmt.Errorf("window %d: unknown split %q", i+1, w.Split)
+		}
+	}
+	return nil
+}
+
+// projectLayout returns the layout for the project in dir: its own layout
+// file when it has one, or else the layout of the editor
+func (s *Selector) projectLayout(dir string) (LayoutConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProjectLayoutFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return s.config.Editor.Layout, nil
+	}
+	if err != nil {
+		return LayoutConfig{}, err
+	}
+
+	var layout LayoutConfig
+	if err := yaml.Unmarshal(data, &layout); err != nil {
+		return LayoutConfig{}, fmt.Errorf("failed to parse %s: %w", ProjectLayoutFile, err)
+	}
+	if err := ValidateLayout(layout); err != nil {
+		return LayoutConfig{}, fmt.Errorf("invalid %s: %w", ProjectLayoutFile, err)
+	}
+	return layout, nil
+}
+
+// LayoutCommands returns the tmux commands that create the session of a
+// project with its layout, none when there is no layout or the editor runs
+// in a devcontainer
+func (s *Selector) LayoutCommands(dir, title string) ([][]string, error) {
+	if s.inContainer(dir) {
+		return nil, nil
+	}
+	return s.layoutCommands(dir, s.editorData(dir, sanitizeTitle(title)))
+}
+
+// layoutCommands returns the layout commands for the editor data of a project
+func (s *Selector) layoutCommands(dir string, data map[string]string) ([][]string, error) {
+	layout, err := s.projectLayout(dir)
+	if err != nil || len(layout.Windows) == 0 {
+		return nil, err
+	}
+
+	session := data["Session"]
+	target := "=" + session + ":"
+
+	var commands [][]string
+	for i, w := range layout.Windows {
+		panes := w.Panes
+		if len(panes) == 0 {
+			panes = []string{""}
+		}
+
+		// Panes are split off the first one, which stays active; each split
+		// lands right after it, so the other panes are added last to first
+		order := make([]int, 0, len(panes))
+		order = append(order, 0)
+		for j := len(panes) - 1; j > 0; j-- {
+			order = append(order, j)
+		}
+
+		for _, j := range order {
+			shellCommand, err := s.paneCommand(dir, panes[j], data)
+			if err != nil {
+				return nil, err
+			}
+
+			var command []string
+			switch {
+			case i == 0 && j == 0:
+				command = []string{"tmux", "new-session", "-d", "-s", session, "-c", dir}
+			case j == 0:
+				command = []string{"tmux", "new-window", "-t", target, "-c", dir}
+			default:
+				command = []string{"tmux", "split-window", "-d", "-t", target, "-c", dir, splitFlag(w.Split)}
+			}
+			if j == 0 && w.Name != "" {
+				command = append(command, "-n", w.Name)
+			}
+			if shellCommand != "" {
+				command = append(command, shellCommand)
+			}
+			commands = append(commands, command)
+		}
+
+		if tmuxLayout := windowLayout(w, len(panes)); tmuxLayout != "" {
+			commands = append(commands, []string{"tmux", "select-layout", "-t", target, tmuxLayout})
+		}
+	}
+
+	if len(layout.Windows) > 1 {
+		commands = append(commands, []string{"tmux", "select-window", "-t", target + "^"})
+	}
+	return commands, nil
+}
+
+// paneCommand renders the command of a pane, run in the project
+// environment
+func (s *Selector) paneCommand(dir, pane string, data map[string]string) (string, error) {
+	tmpl, err := template.New("pane").Funcs(template.FuncMap{
+		"sanitize": SanitizeForTmux,
+	}).Parse(pane)
+	if err != nil {
+		return "", fmt.Errorf("invalid layout pane %q: %w", pane, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid layout pane %q: %w", pane, err)
+	}
+
+	command := strings.TrimSpace(buf.String())
+	if command == "" {
+		return "", nil
+	}
+	wrapped, args := s.wrapProjectEnv(dir, "sh", []string{"-c", command})
+	if wrapped == "sh" {
+		return command, nil // tmux runs it through the shell already
+	}
+	return ShellJoin(append([]string{wrapped}, args...)), nil
+}
+
+// windowLayout returns the tmux layout of a window: its own, or else
+// panes of even size along the split
+func windowLayout(w LayoutWindow, panes int) string {
+	switch {
+	case w.Layout != "":
+		return w.Layout
+	case panes < 3:
+		return "" // A single split is even already
+	case w.Split == SplitVertical:
+		return "even-vertical"
+	default:
+		return "even-horizontal"
+	}
+}
+
+// splitFlag returns the split-window flag of a split
+func splitFlag(split string) string {
+	if split == SplitVertical {
+		return "-v"
+	}
+	return "-h"
+}
+
+// PrepareLayout creates the tmux session of a project with its layout,
+// unless the session is already running, there is no layout or the editor
+// runs in a devcontainer
+func (s *Selector) PrepareLayout(dir, title string) error {
+	if s.inContainer(dir) {
+		return nil
+	}
+	data := s.editorData(dir, sanitizeTitle(title))
+	commands, err := s.layoutCommands(dir, data)
+	if err != nil || len(commands) == 0 {
+		return err
+	}
+
+	if exec.Command("tmux", "has-session", "-t", "="+data["Session"]).Run() == nil {
+		return nil
+	}
+
+	for _, command := range commands {
+		if output, err := exec.Command(command[0], command[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux %s failed: %w: %s", command[1], err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// liveReloadInterval is how often pending title updates are pushed
+const liveReloadInterval = 250 * time.Millisecond
+
+// liveReload pushes updated entries to a selector that accepts fzf-style
+// HTTP actions (fzf --listen), reloading its list from a temp file
+type liveReload struct {
+	address string
+	key     string
+	file    string
+	client  http.Client
+
+	mu      sync.Mutex
+	entries []string
+	dirty   bool
+}
+
+// newLiveReload picks a free local port for the selector to listen on
+func newLiveReload(entries []string) (*liveReload, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "code-entries-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create entries file: %w", err)
+	}
+	file.Close()
+
+	return &liveReload{
+		address: address,
+		key:     hex.EncodeToString(key),
+		file:    file.Name(),
+		client:  http.Client{Timeout: time.Second},
+		entries: append([]string(nil), entries...),
+	}, nil
+}
+
+// env returns the environment variables the selector needs to accept updates
+func (l *liveReload) env() []string {
+	return []string{"FZF_API_KEY=" + l.key}
+}
+
+// set replaces an entry; it is pushed on the next tick
+func (l *liveReload) set(index int, entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[index] = entry
+	l.dirty = true
+}
+
+// follow pushes pending updates until ctx is cancelled. Pushes that fail,
+// e.g. because the selector is not listening yet, are retried.
+func (l *liveReload) follow(ctx context.Context) {
+	ticker := time.NewTicker(liveReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.push()
+		}
+	}
+}
+
+// push writes the current entries and asks the selector to reload them
+func (l *liveReload) push() {
+	l.mu.Lock()
+	if !l.dirty {
+		l.mu.Unlock()
+		return
+	}
+	data := strings.Join(l.entries, "\n")
+	l.dirty = false
+	l.mu.Unlock()
+
+	if err := l.send(data); err != nil {
+		l.mu.Lock()
+		l.dirty = true
+		l.mu.Unlock()
+	}
+}
+
+// send delivers a reload action for data to the selector
+func (l *liveReload) send(data string) error {
+	tmpFile := l.file + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(data), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, l.file); err != nil {
+		return err
+	}
+
+	action := fmt.Sprintf("reload(cat %s)", shellQuote(l.file))
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.address, strings.NewReader(action))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", l.key)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("selector rejected update: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close removes the entries file
+func (l *liveReload) Close() error {
+	return os.Remove(l.file)
+}
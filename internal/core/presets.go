@@ -0,0 +1,245 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/tui"
+	"gopkg.in/yaml.v3"
+)
+
+// selectorPresets are the selectors that can be referenced by name,
+// e.g. `selector: fzf`
+var selectorPresets = map[string]SelectorConfig{
+	"fuzzel": {
+		Command: "fuzzel",
+		Args:    []string{"--dmenu", "--prompt=Project: "},
+	},
+	"fzf": {
+		Command:     "fzf",
+		Args:        []string{"--prompt=Project > ", "--height=40%", "--layout=reverse", "--multi"},
+		PreviewArgs: []string{"--preview={{.Command}} {}", "--preview-window=right,50%"},
+		ListenArgs:  []string{"--listen={{.Address}}"},
+		ExpectArgs:  []string{"--expect={{.Keys}}"},
+		ThemeArgs:   []string{"--color=fg:{{.Foreground}},bg:{{.Background}},hl:{{.Match}},fg+:{{.Accent}},hl+:{{.Match}},prompt:{{.Accent}},pointer:{{.Accent}},marker:{{.Accent}}"},
+		Actions:     defaultActions,
+		Cancel:      CancelPolicy{ExitCodes: []int{1, 130}}, // 1 when nothing matches, 130 on Esc or Ctrl-C
+	},
+	"rofi": {
+		Command: "rofi",
+		Args:    []string{"-dmenu", "-i", "-multi-select", "-p", "Project"},
+		ThemeArgs: []string{"-theme-str", "* { background-color: {{.Background}}; text-color: {{.Foreground}}; } " +
+			"element selected { text-color: {{.Accent}}; } element-text { highlight: bold {{.Match}}; }"},
+	},
+	"wofi": {
+		Command: "wofi",
+		Args:    []string{"--dmenu", "--insensitive", "--prompt=Project"},
+	},
+	"tofi": {
+		Command: "tofi",
+		Args:    []string{"--prompt-text=Project: ", "--fuzzy-match=true"},
+	},
+	"bemenu": {
+		Command: "bemenu",
+		Args:    []string{"-i", "-p", "Project:"},
+	},
+	"dmenu": {
+		Command: "dmenu",
+		Args:    []string{"-i", "-p", "Project:"},
+	},
+	BuiltinSelector: {
+		Command: BuiltinSelector,
+		Actions: defaultActions,
+	},
+	NumberedSelector: {
+		Command: NumberedSelector,
+	},
+}
+
+// SelectorPreset returns the selector settings of a named preset
+func SelectorPreset(name string) (SelectorConfig, bool) {
+	preset, ok := selectorPresets[name]
+	if !ok {
+		return SelectorConfig{}, false
+	}
+	preset.Args = append([]string(nil), preset.Args...)
+	return preset, true
+}
+
+// SelectorPresetNames returns the names of the selector presets in order
+func SelectorPresetNames() []string {
+	return sortedKeys(selectorPresets)
+}
+
+// TerminalPresetNames returns the names of the terminal presets in order
+func TerminalPresetNames() []string {
+	return sortedKeys(terminalPresets)
+}
+
+// EditorPresetNames returns the names of the editor presets in order
+func EditorPresetNames() []string {
+	return sortedKeys(editorPresets)
+}
+
+// sortedKeys returns the names of presets in order
+func sortedKeys[T any](presets map[string]T) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnmarshalYAML accepts either a preset name or a full selector definition
+func (s *SelectorConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		preset, ok := SelectorPreset(value.Value)
+		if !ok {
+			return fmt.Errorf("unknown selector preset %q (available: %s)", value.Value, strings.Join(sortedKeys(selectorPresets), ", "))
+		}
+		*s = preset
+		return nil
+	}
+
+	type plain SelectorConfig
+	return value.Decode((*plain)(s))
+}
+
+// defaultSelectorChain lists the graphical selectors tried when none is
+// configured, falling back to the built-in finder
+func defaultSelectorChain() []SelectorConfig {
+	var chain []SelectorConfig
+	for _, name := range []string{"fuzzel", "rofi", "wofi", BuiltinSelector} {
+		preset, _ := SelectorPreset(name)
+		chain = append(chain, preset)
+	}
+	return chain
+}
+
+// terminalSelector picks the selector used when running from a terminal:
+// fzf when installed, the built-in finder otherwise
+func terminalSelector() SelectorConfig {
+	if _, err := exec.LookPath("fzf"); err == nil {
+		preset, _ := SelectorPreset("fzf")
+		return preset
+	}
+	preset, _ := SelectorPreset(BuiltinSelector)
+	return preset
+}
+
+// interactiveTerminal reports whether the launcher was started from a
+// shell rather than a window manager keybinding
+func interactiveTerminal() bool {
+	return tui.IsTerminal(os.Stdout)
+}
+
+// editorPresets are editors that can be referenced by name, e.g.
+// `editor: vscode`. GUI editors open projects in a new window of their own.
+var editorPresets = map[string]EditorConfig{
+	"kitty-tmux": DefaultConfig().Editor,
+	"tmux-nvim": { // Runs inside the configured terminal
+		Args: "sh -c \"tmux new -c {{.Dir}} -A -s {{.Session}} nvim {{.Dir}} {{.ExtraArgs}}\"",
+	},
+	"nvim": { // Runs inside the configured terminal
+		Args: "nvim {{.Dir}}",
+	},
+	"kitty": { // Neovim straight in the terminal, without a tmux session
+		Command: "kitty",
+		Args:    "-d {{.Dir}} -T {{.Title}} --class {{.Title}} nvim {{.Dir}} {{.ExtraArgs}}",
+		Here:    "nvim {{.Dir}} {{.ExtraArgs}}",
+	},
+	"vscode": {
+		Command: "code",
+		Args:    "--new-window {{.Dir}}",
+		App:     "code",
+	},
+	"codium": {
+		Command: "codium",
+		Args:    "--new-window {{.Dir}}",
+		App:     "codium",
+	},
+	"zed": {
+		Command: "zed",
+		Args:    "--new {{.Dir}}",
+		App:     "dev.zed.Zed",
+	},
+	"goland": {
+		Command: "goland",
+		Args:    "{{.Dir}}",
+		App:     "jetbrains-goland",
+	},
+	"idea": {
+		Command: "idea",
+		Args:    "{{.Dir}}",
+		App:     "jetbrains-idea",
+	},
+	"pycharm": {
+		Command: "pycharm",
+		Args:    "{{.Dir}}",
+		App:     "jetbrains-pycharm",
+	},
+}
+
+// terminalPresets are terminal emulators that can be referenced by name,
+// e.g. `terminal: foot`
+var terminalPresets = map[string]TerminalConfig{
+	"kitty": {
+		Command: "kitty",
+		Args:    "-d {{.Dir}} -T {{.Title}} --class {{.Title}}",
+	},
+	"alacritty": {
+		Command: "alacritty",
+		Args:    "--working-directory {{.Dir}} --title {{.Title}} --class {{.Title}}",
+		Exec:    "-e",
+	},
+	"foot": {
+		Command: "foot",
+		Args:    "--working-directory={{.Dir}} --title={{.Title}} --app-id={{.Title}}",
+	},
+	"wezterm": { // The title is left to the program, wezterm cannot set it
+		Command: "wezterm",
+		Args:    "start --cwd {{.Dir}} --class {{.Title}}",
+		Exec:    "--",
+	},
+	"ghostty": { // Ghostty classes must be application IDs, so only the title is set
+		Command: "ghostty",
+		Args:    "--working-directory={{.Dir}} --title={{.Title}}",
+		Exec:    "-e",
+	},
+}
+
+// UnmarshalYAML accepts either a terminal preset name or a full terminal
+// definition
+func (t *TerminalConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		preset, ok := terminalPresets[value.Value]
+		if !ok {
+			return fmt.Errorf("unknown terminal preset %q (available: %s)", value.Value, strings.Join(sortedKeys(terminalPresets), ", "))
+		}
+		*t = preset
+		return nil
+	}
+
+	type plain TerminalConfig
+	return value.Decode((*plain)(t))
+}
+
+// UnmarshalYAML accepts either an editor preset name or a full editor
+// definition
+func (e *EditorConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		preset, ok := editorPresets[value.Value]
+		if !ok {
+			return fmt.Errorf("unknown editor preset %q (available: %s)", value.Value, strings.Join(sortedKeys(editorPresets), ", "))
+		}
+		*e = preset
+		return nil
+	}
+
+	type plain EditorConfig
+	return value.Decode((*plain)(e))
+}
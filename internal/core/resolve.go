@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/fuzzy"
+)
+
+// ErrNoMatch is returned when a name matches no project
+var ErrNoMatch = errors.New("no matching project")
+
+// maxAmbiguousShown limits the candidates listed in ambiguity errors
+const maxAmbiguousShown = 5
+
+// ResolveProject finds the project a name refers to, trying in order an
+// exact match of the path or its last element, a unique prefix of either,
+// and finally the best fuzzy match
+func ResolveProject(name string, projects []string) (string, error) {
+	name = strings.TrimSuffix(name, "/")
+	match, err := ResolveProjectStrict(name, projects)
+	if !errors.Is(err, ErrNoMatch) || name == "" {
+		return match, err
+	}
+
+	matches := fuzzy.Filter(name, projects)
+	if len(matches) == 0 {
+		return "", err
+	}
+	return matches[0].Str, nil
+}
+
+// ResolveProjectStrict is ResolveProject without the fuzzy match, for
+// commands that must not act on a project the user did not name
+func ResolveProjectStrict(name string, projects []string) (string, error) {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return "", ErrNoMatch
+	}
+
+	if match, err := uniqueMatch(name, projects, func(project string) bool {
+		return project == name || filepath.Base(project) == name
+	}); match != "" || err != nil {
+		return match, err
+	}
+
+	if match, err := uniqueMatch(name, projects, func(project string) bool {
+		return strings.HasPrefix(project, name) || strings.HasPrefix(filepath.Base(project), name)
+	}); match != "" || err != nil {
+		return match, err
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoMatch, name)
+}
+
+// uniqueMatch returns the only project accepted by match, nothing when no
+// project is accepted and an error listing the candidates when several are.
+// A project whose full path equals name always wins.
+func uniqueMatch(name string, projects []string, match func(project string) bool) (string, error) {
+	var candidates []string
+	for _, project := range projects {
+		if project == name {
+			return project, nil
+		}
+		if match(project) {
+			candidates = append(candidates, project)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", nil
+	case 1:
+		return candidates[0], nil
+	default:
+		shown := candidates[:min(len(candidates), maxAmbiguousShown)]
+		more := ""
+		if len(candidates) > len(shown) {
+			more = fmt.Sprintf(" and %d more", len(candidates)-len(shown))
+		}
+		return "", fmt.Errorf("%q is ambiguous: %s%s", name, strings.Join(shown, ", "), more)
+	}
+}
+
+// FilterProjects keeps the projects that fuzzy-match pattern, best matches
+// first
+func FilterProjects(pattern string, projects []string) []string {
+	matches := fuzzy.Filter(pattern, projects)
+	filtered := make([]string, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.Str
+	}
+	return filtered
+}
@@ -0,0 +1,61 @@
+package core
+
+import "fmt"
+
+// EditorRule picks an editor profile for projects of a language or with a
+// file at their root
+type EditorRule struct {
+	Language string `yaml:"language"` // As detected for {{.Language}}, e.g. Rust
+	File     string `yaml:"file"`     // Glob matched at the project root, e.g. pom.xml
+	Editor   string `yaml:"editor"`   // Editor profile to open the project with
+}
+
+// matches reports whether the project in dir of the given language
+// satisfies every condition of the rule
+func (r EditorRule) matches(dir, language string) bool {
+	if r.Language != "" && r.Language != language {
+		return false
+	}
+	if r.File != "" && !hasRootFile(dir, r.File) {
+		return false
+	}
+	return true
+}
+
+// ValidateEditorRules checks that every rule has a condition and names a
+// known editor profile
+func ValidateEditorRules(rules []EditorRule, editors map[string]EditorConfig) error {
+	for i, rule := range rules {
+		if rule.Language == "" && rule.File == "" {
+			return fmt.Errorf("rule %d: needs a language or file", i+1)
+		}
+		if _, ok := editors[rule.Editor]; !ok {
+			return fmt.Errorf("rule %d: unknown editor profile %q", i+1, rule.Editor)
+		}
+	}
+	return nil
+}
+
+// ForProject returns the selector to open the project in dir with: a copy
+// using the editor profile of the first matching editor rule, or s itself
+// when no rule matches or a profile was picked explicitly
+func (s *Selector) ForProject(dir string) *Selector {
+	if s.editorProfile != "" || len(s.config.EditorRules) == 0 {
+		return s
+	}
+
+	language := DetectLanguage(dir)
+	for _, rule := range s.config.EditorRules {
+		if !rule.matches(dir, language) {
+			continue
+		}
+		config := *s.config
+		project := *s
+		project.config = &config
+		if err := project.UseEditor(rule.Editor); err != nil {
+			return s // Rules are validated on load
+		}
+		return &project
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+)
+
+// Session naming strategies accepted by session_naming
+const (
+	SessionNamingBasename = "basename" // api
+	SessionNamingParent   = "parent"   // work_api
+	SessionNamingHash     = "hash"     // api_1a2b3c
+)
+
+// ValidateSessionNaming reports whether naming is a known strategy
+func ValidateSessionNaming(naming string) error {
+	switch naming {
+	case "", SessionNamingBasename, SessionNamingParent, SessionNamingHash:
+		return nil
+	default:
+		return fmt.Errorf("unknown session naming %q", naming)
+	}
+}
+
+// SessionNames returns the tmux session names of the project in dir, in the
+// order they are tried: the name of the strategy first, then more specific
+// ones for when a session of that name belongs to another project. The
+// hash suffixed name, unique to the path, always comes last.
+func SessionNames(naming, dir string) []string {
+	dir = filepath.Clean(dir)
+	base := SanitizeForTmux(filepath.Base(dir))
+	parent := SanitizeForTmux(filepath.Base(filepath.Dir(dir))) + "_" + base
+	sum := sha1.Sum([]byte(dir))
+	hashed := base + "_" + hex.EncodeToString(sum[:3])
+
+	switch naming {
+	case SessionNamingParent:
+		return []string{parent, hashed}
+	case SessionNamingHash:
+		return []string{hashed}
+	default:
+		return []string{base, parent, hashed}
+	}
+}
+
+// SetSessionSuffix appends suffix to the session names of projects, so a
+// second window of a project gets a session of its own
+func (s *Selector) SetSessionSuffix(suffix string) {
+	s.sessionSuffix = suffix
+}
+
+// SessionNaming returns the session naming strategy in use
+func (s *Selector) SessionNaming() string {
+	return s.config.SessionNaming
+}
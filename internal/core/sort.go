@@ -0,0 +1,64 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort modes accepted by the sort setting
+const (
+	SortMRU              = "mru"
+	SortFrecency         = "frecency"
+	SortAlpha            = "alpha"
+	SortRecentlyModified = "recently-modified"
+)
+
+// ValidateSortMode reports whether mode is a known sort mode
+func ValidateSortMode(mode string) error {
+	switch mode {
+	case "", SortMRU, SortFrecency, SortAlpha, SortRecentlyModified:
+		return nil
+	default:
+		return fmt.Errorf("unknown sort mode %q (want %s, %s, %s or %s)",
+			mode, SortMRU, SortFrecency, SortAlpha, SortRecentlyModified)
+	}
+}
+
+// SortAlphabetically orders projects by path, ignoring case
+func SortAlphabetically(projects []string) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i]) < strings.ToLower(projects[j])
+	})
+}
+
+// SortByScore orders projects by score, highest first. Projects with the
+// same score keep their current order.
+func SortByScore(projects []string, score func(project string) float64) {
+	scores := make(map[string]float64, len(projects))
+	for _, project := range projects {
+		scores[project] = score(project)
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return scores[projects[i]] > scores[projects[j]]
+	})
+}
+
+// ModifiedTime returns when a project last changed: the last commit or
+// checkout recorded in the reflog, then the last change to the git index,
+// then the modification time of the directory itself
+func ModifiedTime(dir string) time.Time {
+	for _, path := range []string{
+		filepath.Join(dir, ".git", "logs", "HEAD"),
+		filepath.Join(dir, ".git", "index"),
+		dir,
+	} {
+		if info, err := os.Stat(path); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// ThemeConfig is the palette passed to selectors that accept colors
+type ThemeConfig struct {
+	Foreground string `yaml:"foreground"` // Colors are "#rrggbb"
+	Background string `yaml:"background"`
+	Accent     string `yaml:"accent"` // Prompt and highlighted entry
+	Match      string `yaml:"match"`  // Matched characters
+}
+
+// hexColor matches the colors accepted in the theme
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidateTheme checks that a theme is either unset or a complete palette
+// of hex colors
+func ValidateTheme(theme ThemeConfig) error {
+	if theme == (ThemeConfig{}) {
+		return nil
+	}
+	for _, color := range []struct{ name, value string }{
+		{"foreground", theme.Foreground},
+		{"background", theme.Background},
+		{"accent", theme.Accent},
+		{"match", theme.Match},
+	} {
+		if !hexColor.MatchString(color.value) {
+			return fmt.Errorf("%s must be a color such as #1e1e2e, got %q", color.name, color.value)
+		}
+	}
+	return nil
+}
+
+// colors returns the palette by template key
+func (t ThemeConfig) colors() map[string]string {
+	return map[string]string{
+		"Foreground": t.Foreground,
+		"Background": t.Background,
+		"Accent":     t.Accent,
+		"Match":      t.Match,
+	}
+}
+
+// active reports whether colors should be used: a theme is configured and
+// NO_COLOR is not set
+func (t ThemeConfig) active() bool {
+	return t != (ThemeConfig{}) && os.Getenv("NO_COLOR") == ""
+}
+
+// themeArgs renders the theme arguments of a selector, nothing when the
+// theme is not active
+func (c *Config) themeArgs(selector SelectorConfig) []string {
+	if !c.Theme.active() {
+		return nil
+	}
+	return renderSelectorArgs(selector.ThemeArgs, c.Theme.colors())
+}
@@ -0,0 +1,182 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Operations a request can ask for
+const (
+	OpStatus   = "status"   // Report on the daemon
+	OpProjects = "projects" // Return the indexed projects of a base dir
+	OpOpen     = "open"     // Resolve a project name and open it
+	OpStop     = "stop"     // Shut the daemon down
+)
+
+var (
+	// ErrNotRunning is returned by Call when no daemon listens on the socket
+	ErrNotRunning = errors.New("daemon is not running")
+	// ErrDeclined is returned by Call when the daemon runs with other
+	// settings than the client, which should then do the work itself
+	ErrDeclined = errors.New("daemon declined the request")
+)
+
+// Request is a query or launch request sent to the daemon, one per
+// connection as a JSON line
+type Request struct {
+	Op string `json:"op"`
+
+	// Settings the client would use, answered only when they match the
+	// daemon's
+	Profile   string   `json:"profile,omitempty"`
+	BaseDir   string   `json:"base_dir,omitempty"`
+	ScanDepth int      `json:"scan_depth,omitempty"`
+	SkipDirs  []string `json:"skip_dirs,omitempty"`
+
+	// Project name and launch options of open requests
+	Project   string   `json:"project,omitempty"`
+	Editor    string   `json:"editor,omitempty"`
+	NewWindow bool     `json:"new_window,omitempty"`
+	ExtraArgs []string `json:"extra_args,omitempty"`
+}
+
+// Response answers a request. Error is set when the request failed, and
+// Declined when the daemon left it to the client.
+type Response struct {
+	Error    string    `json:"error,omitempty"`
+	Declined bool      `json:"declined,omitempty"`
+	Projects []string  `json:"projects,omitempty"`
+	Project  string    `json:"project,omitempty"`
+	PID      int       `json:"pid,omitempty"`
+	BaseDir  string    `json:"base_dir,omitempty"`
+	Scanned  time.Time `json:"scanned"`
+}
+
+// Handler answers a request
+type Handler func(Request) Response
+
+// DefaultSocket returns the socket location under the XDG runtime dir
+func DefaultSocket() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "code", "daemon.sock")
+	}
+	return filepath.Join(os.TempDir(), "code-"+strconv.Itoa(os.Getuid()), "daemon.sock")
+}
+
+// Listen creates the socket at path, replacing one left behind by a daemon
+// that is gone
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket dir: %w", err)
+	}
+	if err := checkSocketDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// checkSocketDir refuses a socket dir that is a symlink, belongs to
+// another user or is open to others, who could then swap the socket
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("socket dir %s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket dir %s is not owned by the current user", dir)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		return fmt.Errorf("socket dir %s has mode %#o, want 0700", dir, perm)
+	}
+	return nil
+}
+
+// Serve answers the requests of each connection to l until l is closed,
+// then waits for the requests being answered
+func Serve(l net.Listener, handle Handler) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(conn, handle)
+		}()
+	}
+}
+
+// serveConn reads a request from conn and writes its response
+func serveConn(conn net.Conn, handle Handler) {
+	defer conn.Close()
+
+	var req Request
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	resp := Response{Error: "invalid request"}
+	if err == nil {
+		resp = handle(req)
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// Call sends a request to the daemon listening on path and waits up to
+// timeout for its response. A failed request is returned as an error.
+func Call(path string, req Request, timeout time.Duration) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return Response{}, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Declined {
+		return resp, fmt.Errorf("%w: %s", ErrDeclined, resp.Error)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
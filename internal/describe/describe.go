@@ -0,0 +1,212 @@
+package describe
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Read returns the description of the project in dir, taken from the
+// description field of package.json or Cargo.toml, or else the first line
+// of text in the README. Projects without one get an empty string.
+func Read(dir string) string {
+	for _, read := range []func(string) string{fromPackageJSON, fromCargoToml, fromReadme} {
+		if description := read(dir); description != "" {
+			return description
+		}
+	}
+	return ""
+}
+
+// fromPackageJSON reads the description field of package.json
+func fromPackageJSON(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var manifest struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(manifest.Description)
+}
+
+// fromCargoToml reads the description key of the [package] table of
+// Cargo.toml
+func fromCargoToml(dir string) string {
+	file, err := os.Open(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inPackage := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inPackage = line == "[package]"
+			continue
+		}
+		if !inPackage {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "description" {
+			return unquoteToml(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// unquoteToml unquotes a basic or literal TOML string
+func unquoteToml(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return strings.TrimSpace(unquoted)
+	}
+	return ""
+}
+
+// fromReadme returns the first line of prose in the README, skipping
+// headings, badges and HTML
+func fromReadme(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "[Rr][Ee][Aa][Dd][Mm][Ee]*"))
+	if len(matches) == 0 {
+		return ""
+	}
+
+	file, err := os.Open(matches[0])
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.ContainsAny(line[:1], "#=-<![") || strings.HasPrefix(line, "```") {
+			continue
+		}
+		return line
+	}
+	return ""
+}
+
+// Cache remembers the description of each project between runs so it can
+// be shown without reading the manifests again
+type Cache struct {
+	filename string
+	mu       sync.Mutex
+	entries  map[string]string
+	dirty    bool
+	hits     int
+	misses   int
+}
+
+// DefaultCacheFile returns the cache location under the XDG cache dir
+func DefaultCacheFile() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "code", "descriptions.json")
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "code", "descriptions.json")
+}
+
+// NewCache loads the cache from filename. A missing or unreadable cache
+// starts empty.
+func NewCache(filename string) *Cache {
+	c := &Cache{
+		filename: filename,
+		entries:  make(map[string]string),
+	}
+	if data, err := os.ReadFile(filename); err == nil {
+		json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// Get returns the cached description of dir
+func (c *Cache) Get(dir string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	description, ok := c.entries[dir]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return description, ok
+}
+
+// Lookups returns how many calls to Get found a value and how many did not
+func (c *Cache) Lookups() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Put records the description of dir
+func (c *Cache) Put(dir, description string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, ok := c.entries[dir]; ok && previous == description {
+		return
+	}
+	c.entries[dir] = description
+	c.dirty = true
+}
+
+// Delete forgets the description of dir
+func (c *Cache) Delete(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[dir]; ok {
+		delete(c.entries, dir)
+		c.dirty = true
+	}
+}
+
+// Save atomically writes the cache if it changed
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode description cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	tempFile := c.filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write description cache: %w", err)
+	}
+	if err := os.Rename(tempFile, c.filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
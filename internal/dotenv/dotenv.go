@@ -0,0 +1,78 @@
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// name matches the variable names accepted in files
+var name = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Read reads the variables of a dotenv file
+func Read(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// Parse reads KEY=value lines, optionally prefixed with export. Values may
+// be single quoted, taken literally, or double quoted, with \n, \" and \\
+// escapes; unquoted values end at " #". Blank lines and lines starting
+// with # are skipped.
+func Parse(r io.Reader) (map[string]string, error) {
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || !name.MatchString(key) {
+			return nil, fmt.Errorf("line %d: want NAME=value", lineNo)
+		}
+
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
+
+// parseValue unquotes a value
+func parseValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double quoted value")
+		}
+		return strconv.Unquote(quoted)
+	default:
+		value, _, _ = strings.Cut(value, " #")
+		return strings.TrimSpace(value), nil
+	}
+}
@@ -0,0 +1,105 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scoring weights for a match
+const (
+	matchScore       = 16
+	consecutiveBonus = 24
+	boundaryBonus    = 20
+	firstCharBonus   = 12
+	gapPenalty       = 1
+)
+
+// Match is a candidate that matched a pattern
+type Match struct {
+	Index     int    // Position of the candidate in the input slice
+	Str       string // The candidate itself
+	Score     int
+	Positions []int // Rune offsets of the matched characters
+}
+
+// Score matches pattern against s as a case-insensitive subsequence.
+// It returns false when s does not contain every pattern character in order.
+func Score(pattern, s string) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	runes := []rune(s)
+	lower := []rune(strings.ToLower(s))
+
+	positions := make([]int, 0, len(p))
+	score := 0
+	pi := 0
+	last := -1
+
+	for i := 0; i < len(lower) && pi < len(p); i++ {
+		if lower[i] != p[pi] {
+			continue
+		}
+
+		score += matchScore
+		switch {
+		case i == 0:
+			score += firstCharBonus + boundaryBonus
+		case isBoundary(runes[i-1], runes[i]):
+			score += boundaryBonus
+		}
+		if last >= 0 {
+			if i == last+1 {
+				score += consecutiveBonus
+			} else {
+				score -= (i - last - 1) * gapPenalty
+			}
+		}
+
+		positions = append(positions, i)
+		last = i
+		pi++
+	}
+
+	if pi < len(p) {
+		return 0, nil, false
+	}
+
+	// Prefer shorter candidates when everything else is equal
+	score -= len(runes) - len(p)
+	return score, positions, true
+}
+
+// isBoundary reports whether cur starts a new word after prev
+func isBoundary(prev, cur rune) bool {
+	switch prev {
+	case '/', '-', '_', '.', ' ':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// Filter returns the candidates matching pattern, best first.
+// Candidates with equal scores keep their original order, so a list that
+// is already ranked (MRU first) stays ranked among equal matches.
+func Filter(pattern string, candidates []string) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for i, c := range candidates {
+		score, positions, ok := Score(pattern, c)
+		if !ok {
+			continue
+		}
+		matches = append(matches, Match{Index: i, Str: c, Score: score, Positions: positions})
+	}
+
+	if pattern != "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Score > matches[j].Score
+		})
+	}
+
+	return matches
+}
@@ -0,0 +1,186 @@
+package gitstatus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the branch and working tree state of a repository
+type Status struct {
+	Branch  string    `json:"branch"`
+	Dirty   bool      `json:"dirty"`
+	Checked time.Time `json:"checked"`
+}
+
+// Read runs git status in dir
+func Read(ctx context.Context, dir string) (Status, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain", "--branch").Output()
+	if err != nil {
+		return Status{}, fmt.Errorf("git status failed: %w", err)
+	}
+
+	status := Status{Checked: time.Now()}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "## "); ok {
+			status.Branch = parseBranch(header)
+		} else if line != "" {
+			status.Dirty = true
+		}
+	}
+	return status, nil
+}
+
+// RemoteURL returns the URL of the origin remote of the repository in dir
+func RemoteURL(ctx context.Context, dir string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", fmt.Errorf("git remote failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Unpushed counts the commits of local branches of the repository in dir
+// that no remote branch contains
+func Unpushed(ctx context.Context, dir string) (int, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-list", "--count", "--branches", "--not", "--remotes").Output()
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list failed: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// Stashes counts the stash entries of the repository in dir
+func Stashes(ctx context.Context, dir string) (int, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "stash", "list").Output()
+	if err != nil {
+		return 0, fmt.Errorf("git stash failed: %w", err)
+	}
+	return strings.Count(string(output), "\n"), nil
+}
+
+// parseBranch extracts the branch name from a `git status --branch` header
+// such as "main...origin/main [ahead 1]" or "No commits yet on main"
+func parseBranch(header string) string {
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	branch, _, _ := strings.Cut(header, "...")
+	branch, _, _ = strings.Cut(branch, " ")
+	return branch
+}
+
+// Cache remembers the last status of each repository between runs so it
+// can be shown while a fresh status is computed
+type Cache struct {
+	filename string
+	mu       sync.Mutex
+	entries  map[string]Status
+	dirty    bool
+	hits     int
+	misses   int
+}
+
+// DefaultCacheFile returns the cache location under the XDG cache dir
+func DefaultCacheFile() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "code", "git_status.json")
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "code", "git_status.json")
+}
+
+// NewCache loads the cache from filename. A missing or unreadable cache
+// starts empty.
+func NewCache(filename string) *Cache {
+	c := &Cache{
+		filename: filename,
+		entries:  make(map[string]Status),
+	}
+	if data, err := os.ReadFile(filename); err == nil {
+		json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// Get returns the cached status of dir
+func (c *Cache) Get(dir string) (Status, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.entries[dir]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return status, ok
+}
+
+// Lookups returns how many calls to Get found a value and how many did not
+func (c *Cache) Lookups() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Put records the status of dir
+func (c *Cache) Put(dir string, status Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[dir] = status
+	c.dirty = true
+}
+
+// Delete forgets the status of dir
+func (c *Cache) Delete(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[dir]; ok {
+		delete(c.entries, dir)
+		c.dirty = true
+	}
+}
+
+// Save atomically writes the cache if it changed
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode git status cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	tempFile := c.filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write git status cache: %w", err)
+	}
+	if err := os.Rename(tempFile, c.filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,100 @@
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNoUpstream is returned by AheadBehind for branches that track no
+// remote branch
+var ErrNoUpstream = errors.New("no upstream branch")
+
+// Fetch fetches every remote of the repository in dir, pruning deleted
+// branches. Git never prompts for credentials, so a repository needing
+// them fails rather than blocking the others.
+func Fetch(ctx context.Context, dir string) error {
+	_, err := runRemote(ctx, dir, "fetch", "--all", "--prune", "--quiet")
+	return err
+}
+
+// FastForward merges the upstream of the current branch of the
+// repository in dir when that is a fast-forward
+func FastForward(ctx context.Context, dir string) error {
+	_, err := runRemote(ctx, dir, "merge", "--ff-only", "--quiet", "@{upstream}")
+	return err
+}
+
+// AheadBehind counts the commits the current branch of the repository in
+// dir has that its upstream does not, and the other way round
+func AheadBehind(ctx context.Context, dir string) (ahead, behind int, err error) {
+	if _, err := runRemote(ctx, dir, "rev-parse", "--verify", "--quiet", "@{upstream}"); err != nil {
+		return 0, 0, ErrNoUpstream
+	}
+	output, err := runRemote(ctx, dir, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return 0, 0, err
+	}
+	left, right, _ := strings.Cut(strings.TrimSpace(output), "\t")
+	if ahead, err = strconv.Atoi(left); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	if behind, err = strconv.Atoi(right); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	return ahead, behind, nil
+}
+
+// DefaultBranch returns the branch origin/HEAD points at, or else main or
+// master when the repository in dir has one
+func DefaultBranch(ctx context.Context, dir string) (string, error) {
+	if output, err := runRemote(ctx, dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(output), "origin/"), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := runRemote(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", errors.New("no default branch")
+}
+
+// runRemote runs git in dir without prompts and returns its output, with
+// what git reported on failure in the error
+func runRemote(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		if message := gitError(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// gitError picks the first fatal or error line of the output of git,
+// which hints follow, or else its last line
+func gitError(stderr string) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	for _, line := range lines {
+		for _, prefix := range []string{"fatal: ", "error: "} {
+			if message, ok := strings.CutPrefix(line, prefix); ok {
+				return message
+			}
+		}
+	}
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,282 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/backup"
+)
+
+// Event kinds recorded in the history log
+const (
+	EventOpen  = "" // Empty for compatibility with logs written before sessions
+	EventClose = "close"
+)
+
+// Entry is a single event recorded in the history log.
+// Close events carry the length of the editor session that ended.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Project  string        `json:"project"`
+	Event    string        `json:"event,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Record is a history entry with the time spent until the next launch.
+// Duration is zero for the most recent entry, which is still ongoing.
+type Record struct {
+	Entry
+	Duration time.Duration
+}
+
+// Log is an append-only history of project launches stored as JSON lines
+type Log struct {
+	filename string
+	backups  *backup.Rotator
+}
+
+// NewLog creates a history log backed by filename
+func NewLog(filename string) *Log {
+	return &Log{filename: filename}
+}
+
+// SetBackups enables rotated backups of the log before each write
+func (l *Log) SetBackups(r *backup.Rotator) {
+	l.backups = r
+}
+
+// Append records a launch of project at the current time
+func (l *Log) Append(project string) error {
+	return l.write(Entry{Time: time.Now(), Project: project})
+}
+
+// AppendClose records that the editor session of project ended at end
+// after running for the given duration
+func (l *Log) AppendClose(project string, end time.Time, duration time.Duration) error {
+	return l.write(Entry{Time: end, Project: project, Event: EventClose, Duration: duration})
+}
+
+// write appends a single entry to the log
+func (l *Log) write(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	// Backups are best effort and must never lose the entry
+	_ = l.backups.Snapshot(l.filename)
+	file, err := os.OpenFile(l.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// Records reads the launches in the log in chronological order, deriving
+// the duration of each launch from the timestamp of the next one
+func (l *Log) Records() ([]Record, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		if entry.Event == EventOpen {
+			records = append(records, Record{Entry: entry})
+		}
+	}
+
+	for i := 0; i < len(records)-1; i++ {
+		records[i].Duration = records[i+1].Time.Sub(records[i].Time)
+	}
+
+	return records, nil
+}
+
+// Sessions returns the ended editor sessions in chronological order.
+// Each record's Duration is the time the session was observed running.
+func (l *Log) Sessions() ([]Record, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		if entry.Event == EventClose {
+			records = append(records, Record{Entry: entry, Duration: entry.Duration})
+		}
+	}
+	return records, nil
+}
+
+// Entries reads every entry of the log in file order
+func (l *Log) Entries() ([]Entry, error) {
+	file, err := os.Open(l.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip corrupted lines instead of losing the whole log
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Merge adds the entries the log does not have yet, keeping it in
+// chronological order, and returns how many were added
+func (l *Log) Merge(entries []Entry) (int, error) {
+	existing, err := l.Entries()
+	if err != nil {
+		return 0, err
+	}
+
+	type key struct {
+		time    int64
+		project string
+		event   string
+	}
+	seen := make(map[key]bool, len(existing))
+	for _, entry := range existing {
+		seen[key{entry.Time.UnixNano(), entry.Project, entry.Event}] = true
+	}
+
+	added := 0
+	for _, entry := range entries {
+		k := key{entry.Time.UnixNano(), entry.Project, entry.Event}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		existing = append(existing, entry)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(existing, func(i, j int) bool {
+		return existing[i].Time.Before(existing[j].Time)
+	})
+	return added, l.rewrite(existing)
+}
+
+// rewrite atomically replaces the log with entries
+func (l *Log) rewrite(entries []Entry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	tempFile := l.filename + ".tmp"
+	if err := os.WriteFile(tempFile, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	_ = l.backups.Snapshot(l.filename)
+	if err := os.Rename(tempFile, l.filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+	return nil
+}
+
+// Between returns the records whose launch time falls within [since, until).
+// A zero bound is treated as unbounded.
+func Between(records []Record, since, until time.Time) []Record {
+	result := make([]Record, 0, len(records))
+	for _, r := range records {
+		if !since.IsZero() && r.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !r.Time.Before(until) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// ActiveDays counts the distinct days on which each project was opened
+func ActiveDays(records []Record) map[string]int {
+	days := make(map[string]map[string]bool)
+	for _, r := range records {
+		if days[r.Project] == nil {
+			days[r.Project] = make(map[string]bool)
+		}
+		days[r.Project][r.Time.Local().Format("2006-01-02")] = true
+	}
+
+	counts := make(map[string]int, len(days))
+	for project, d := range days {
+		counts[project] = len(d)
+	}
+	return counts
+}
+
+// LastOpened returns the most recent launch time of each project
+func LastOpened(records []Record) map[string]time.Time {
+	last := make(map[string]time.Time)
+	for _, r := range records {
+		if r.Time.After(last[r.Project]) {
+			last[r.Project] = r.Time
+		}
+	}
+	return last
+}
+
+// Frecency scores each project by how often and how recently it was
+// opened: every launch adds a weight that decays with its age
+func Frecency(records []Record, now time.Time) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, r := range records {
+		scores[r.Project] += frecencyWeight(now.Sub(r.Time))
+	}
+	return scores
+}
+
+// frecencyWeight is the score of a single launch of the given age
+func frecencyWeight(age time.Duration) float64 {
+	const day = 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
@@ -0,0 +1,131 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/backup"
+)
+
+// Session is an editor window being tracked until it disappears
+type Session struct {
+	Project  string    `json:"project"`
+	Title    string    `json:"title"`
+	Started  time.Time `json:"started"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Tracker persists the editor sessions that are believed to be running
+type Tracker struct {
+	filename string
+	backups  *backup.Rotator
+}
+
+// NewTracker creates a session tracker backed by filename
+func NewTracker(filename string) *Tracker {
+	return &Tracker{filename: filename}
+}
+
+// SetBackups enables rotated backups of the sessions file before each save
+func (t *Tracker) SetBackups(r *backup.Rotator) {
+	t.backups = r
+}
+
+// Start begins tracking the window of a project.
+// A project that is already tracked keeps its original start time.
+func (t *Tracker) Start(project, title string) error {
+	sessions, err := t.Sessions()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range sessions {
+		if sessions[i].Project == project {
+			sessions[i].Title = title
+			sessions[i].LastSeen = now
+			return t.save(sessions)
+		}
+	}
+
+	sessions = append(sessions, Session{
+		Project:  project,
+		Title:    title,
+		Started:  now,
+		LastSeen: now,
+	})
+	return t.save(sessions)
+}
+
+// Reap checks every tracked session with alive and records the ones whose
+// window is gone as close events in log. Sessions that cannot be checked
+// (no window manager available) are left untouched.
+// The session end is the last time it was seen alive, so running Reap
+// periodically makes the recorded durations more precise.
+func (t *Tracker) Reap(alive func(title string) (bool, error), log *Log) error {
+	sessions, err := t.Sessions()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	remaining := sessions[:0]
+	for _, s := range sessions {
+		ok, err := alive(s.Title)
+		if err != nil {
+			remaining = append(remaining, s)
+			continue
+		}
+		if ok {
+			s.LastSeen = now
+			remaining = append(remaining, s)
+			continue
+		}
+		if err := log.AppendClose(s.Project, s.LastSeen, s.LastSeen.Sub(s.Started)); err != nil {
+			return err
+		}
+	}
+
+	return t.save(remaining)
+}
+
+// Sessions returns the currently tracked sessions
+func (t *Tracker) Sessions() ([]Session, error) {
+	data, err := os.ReadFile(t.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Session{}, nil
+		}
+		return nil, fmt.Errorf("failed to read sessions file: %w", err)
+	}
+
+	var sessions []Session
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse sessions file: %w", err)
+	}
+	return sessions, nil
+}
+
+// save atomically writes the tracked sessions
+func (t *Tracker) save(sessions []Session) error {
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	tempFile := t.filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write sessions file: %w", err)
+	}
+	_ = t.backups.Snapshot(t.filename)
+	if err := os.Rename(tempFile, t.filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+	return nil
+}
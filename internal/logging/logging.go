@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Handler writes records as plain lines for a terminal, e.g.
+// "Warning: failed to focus window err=...", without timestamps
+type Handler struct {
+	w      io.Writer
+	level  slog.Leveler
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+	groups string // Prefix of the keys of attrs added from now on
+}
+
+// New returns a handler writing records of level and above to w
+func New(w io.Writer, level slog.Leveler) *Handler {
+	return &Handler{w: w, level: level, mu: &sync.Mutex{}}
+}
+
+// Enabled reports whether records of a level are written
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes a record as a single line
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	switch {
+	case r.Level >= slog.LevelError:
+		buf.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		buf.WriteString("Warning: ")
+	case r.Level < slog.LevelInfo:
+		buf.WriteString("debug: ")
+	}
+	buf.WriteString(r.Message)
+
+	for _, attr := range h.attrs {
+		writeAttr(&buf, "", attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		writeAttr(&buf, h.groups, attr)
+		return true
+	})
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs returns a handler adding attrs to every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.groups + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler prefixing the keys of later attrs with name
+func (h *Handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.groups += name + "."
+	return &clone
+}
+
+// writeAttr appends " key=value", quoting values with spaces
+func writeAttr(buf *bytes.Buffer, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, member := range attr.Value.Group() {
+			writeAttr(buf, prefix+attr.Key+".", member)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(buf, " %s%s=%s", prefix, attr.Key, value)
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", name)
+	}
+	return level, nil
+}
@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/marianozunino/code/v2/internal/backup"
 )
 
 const (
 	maxMRUItems    = 20
 	tempFileSuffix = ".tmp"
 	bufferSize     = 4096
+
+	// fieldSeparator separates the path from its flags in the MRU file
+	fieldSeparator = "\t"
+	pinnedFlag     = "pinned"
+	openedField    = "opened="
 )
 
 type MRUList struct {
@@ -21,10 +30,13 @@ type MRUList struct {
 	baseDir     string
 	items       []string       // Ordered list for MRU behavior
 	itemSet     map[string]int // O(1) lookup: path -> index
+	pinned      map[string]bool
+	opened      map[string]time.Time // Last time each item was opened
 	dirty       bool
 	lastMod     time.Time
 	mu          sync.RWMutex
 	initialized bool
+	backups     *backup.Rotator
 }
 
 // NewMRUList creates a new MRU list with optimized defaults
@@ -34,10 +46,20 @@ func NewMRUList(filename, baseDir string) *MRUList {
 		baseDir:  baseDir,
 		items:    make([]string, 0, maxMRUItems),
 		itemSet:  make(map[string]int, maxMRUItems),
+		pinned:   make(map[string]bool),
+		opened:   make(map[string]time.Time),
 	}
 	return mru
 }
 
+// SetBackups enables rotated backups of the MRU file before each save
+func (m *MRUList) SetBackups(r *backup.Rotator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.backups = r
+}
+
 // ensureInitialized performs lazy initialization
 func (m *MRUList) ensureInitialized() {
 	if m.initialized {
@@ -55,6 +77,8 @@ func (m *MRUList) loadWithModTime() {
 		// File doesn't exist or can't be accessed
 		m.items = m.items[:0]
 		m.itemSet = make(map[string]int, maxMRUItems)
+		m.pinned = make(map[string]bool)
+		m.opened = make(map[string]time.Time)
 		m.lastMod = time.Time{}
 		return
 	}
@@ -68,6 +92,8 @@ func (m *MRUList) loadWithModTime() {
 		// Reset to empty state on error
 		m.items = m.items[:0]
 		m.itemSet = make(map[string]int, maxMRUItems)
+		m.pinned = make(map[string]bool)
+		m.opened = make(map[string]time.Time)
 		return
 	}
 
@@ -85,6 +111,8 @@ func (m *MRUList) loadFromFile() error {
 	// Clear existing data
 	m.items = m.items[:0]
 	m.itemSet = make(map[string]int, maxMRUItems)
+	m.pinned = make(map[string]bool)
+	m.opened = make(map[string]time.Time)
 
 	scanner := bufio.NewScanner(file)
 	scanner.Buffer(make([]byte, bufferSize), bufferSize*2)
@@ -93,7 +121,7 @@ func (m *MRUList) loadFromFile() error {
 	seenItems := make(map[string]bool, maxMRUItems)
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		line, pinned, opened := parseLine(scanner.Text())
 		if line == "" {
 			continue
 		}
@@ -107,8 +135,11 @@ func (m *MRUList) loadFromFile() error {
 		// Automatic cleanup: verify project still exists
 		if m.projectExists(line) {
 			validItems = append(validItems, line)
-			if len(validItems) >= maxMRUItems {
-				break
+			if pinned {
+				m.pinned[line] = true
+			}
+			if !opened.IsZero() {
+				m.opened[line] = opened
 			}
 		}
 	}
@@ -119,16 +150,49 @@ func (m *MRUList) loadFromFile() error {
 
 	// Update internal structures
 	m.items = append(m.items, validItems...)
-	m.rebuildIndex()
+	m.enforceLimit()
 
 	// Mark as dirty if we removed invalid items
-	if len(validItems) != len(seenItems) {
+	if len(m.items) != len(seenItems) {
 		m.dirty = true
 	}
 
 	return nil
 }
 
+// parseLine splits an MRU file line into the project path, its pinned flag
+// and the time it was last opened. Lines without fields are the legacy
+// format and are treated as unpinned with an unknown open time.
+func parseLine(line string) (string, bool, time.Time) {
+	fields := strings.Split(strings.TrimSpace(line), fieldSeparator)
+	pinned := false
+	var opened time.Time
+	for _, field := range fields[1:] {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == pinnedFlag:
+			pinned = true
+		case strings.HasPrefix(field, openedField):
+			if unix, err := strconv.ParseInt(strings.TrimPrefix(field, openedField), 10, 64); err == nil {
+				opened = time.Unix(unix, 0)
+			}
+		}
+	}
+	return strings.TrimSpace(fields[0]), pinned, opened
+}
+
+// formatLine renders an item in the MRU file format
+func (m *MRUList) formatLine(item string) string {
+	line := item
+	if m.pinned[item] {
+		line += fieldSeparator + pinnedFlag
+	}
+	if opened, ok := m.opened[item]; ok {
+		line += fieldSeparator + openedField + strconv.FormatInt(opened.Unix(), 10)
+	}
+	return line
+}
+
 // projectExists checks if a project path still exists
 func (m *MRUList) projectExists(project string) bool {
 	var fullPath string
@@ -175,7 +239,7 @@ func (m *MRUList) saveAtomic() error {
 				return fmt.Errorf("write error: %w", err)
 			}
 		}
-		if _, err := writer.WriteString(item); err != nil {
+		if _, err := writer.WriteString(m.formatLine(item)); err != nil {
 			file.Close()
 			os.Remove(tempFile)
 			return fmt.Errorf("write error: %w", err)
@@ -197,6 +261,10 @@ func (m *MRUList) saveAtomic() error {
 
 	file.Close()
 
+	// Keep a copy of the previous state; backups are best effort and
+	// must never block the MRU update itself
+	_ = m.backups.Snapshot(m.filename)
+
 	// Atomic rename
 	if err := os.Rename(tempFile, m.filename); err != nil {
 		os.Remove(tempFile)
@@ -218,11 +286,14 @@ func (m *MRUList) Update(project string) error {
 	// Normalize the project path
 	normalizedProject := m.normalizeProject(project)
 
+	m.opened[normalizedProject] = time.Now()
+
 	// O(1) lookup to check if item already exists
 	if existingIndex, exists := m.itemSet[normalizedProject]; exists {
 		// Move existing item to front if it's not already there
 		if existingIndex == 0 {
-			return nil // Already at front
+			m.dirty = true
+			return m.saveAtomic() // Already at front, persist the open time
 		}
 
 		// Remove from current position
@@ -233,23 +304,34 @@ func (m *MRUList) Update(project string) error {
 		m.items = append([]string{normalizedProject}, m.items...)
 		m.rebuildIndex()
 	} else {
-		// Add new item to front
-		if len(m.items) >= maxMRUItems {
-			// Remove oldest item
-			oldestItem := m.items[len(m.items)-1]
-			delete(m.itemSet, oldestItem)
-			m.items = m.items[:len(m.items)-1]
-		}
-
-		// Add to front
+		// Add to front, evicting the oldest item past the limit
 		m.items = append([]string{normalizedProject}, m.items...)
-		m.rebuildIndex()
+		m.enforceLimit()
 	}
 
 	m.dirty = true
 	return m.saveAtomic()
 }
 
+// enforceLimit drops the least recently used items past maxMRUItems and
+// rebuilds the index. Pinned items never count against the size limit.
+func (m *MRUList) enforceLimit() {
+	kept := m.items[:0]
+	unpinned := 0
+	for _, item := range m.items {
+		if !m.pinned[item] {
+			if unpinned >= maxMRUItems {
+				delete(m.opened, item)
+				continue
+			}
+			unpinned++
+		}
+		kept = append(kept, item)
+	}
+	m.items = kept
+	m.rebuildIndex()
+}
+
 // Items returns a copy of the MRU items as relative paths
 func (m *MRUList) Items() []string {
 	m.mu.RLock()
@@ -346,6 +428,8 @@ func (m *MRUList) Remove(project string) error {
 
 	// Remove from index
 	delete(m.itemSet, normalizedProject)
+	delete(m.pinned, normalizedProject)
+	delete(m.opened, normalizedProject)
 	m.rebuildIndex() // Rebuild index as positions have changed
 
 	m.dirty = true
@@ -359,6 +443,8 @@ func (m *MRUList) Clear() error {
 
 	m.items = m.items[:0]
 	m.itemSet = make(map[string]int, maxMRUItems)
+	m.pinned = make(map[string]bool)
+	m.opened = make(map[string]time.Time)
 	m.dirty = true
 
 	return m.saveAtomic()
@@ -384,6 +470,9 @@ func (m *MRUList) Cleanup() error {
 	for _, item := range m.items {
 		if m.projectExists(item) {
 			validItems = append(validItems, item)
+		} else {
+			delete(m.pinned, item)
+			delete(m.opened, item)
 		}
 	}
 
@@ -396,3 +485,148 @@ func (m *MRUList) Cleanup() error {
 
 	return nil
 }
+
+// Pin marks a project as sticky so it is never evicted by Update.
+// Projects not yet in the list are added to the front.
+func (m *MRUList) Pin(project string) error {
+	return m.setPinned(project, true)
+}
+
+// Unpin clears the sticky flag of a project, which then counts against the
+// size limit again and is dropped when the limit is already reached
+func (m *MRUList) Unpin(project string) error {
+	return m.setPinned(project, false)
+}
+
+// setPinned updates the sticky flag of a project and persists the change
+func (m *MRUList) setPinned(project string, pinned bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ensureInitialized()
+
+	normalizedProject := m.normalizeProject(project)
+	if m.pinned[normalizedProject] == pinned {
+		return nil
+	}
+
+	if _, exists := m.itemSet[normalizedProject]; !exists {
+		if !pinned {
+			return nil
+		}
+		m.items = append([]string{normalizedProject}, m.items...)
+	}
+
+	if pinned {
+		m.pinned[normalizedProject] = true
+	} else {
+		delete(m.pinned, normalizedProject)
+	}
+	// An unpinned item counts against the limit again
+	m.enforceLimit()
+
+	m.dirty = true
+	return m.saveAtomic()
+}
+
+// IsPinned checks if a project is marked as sticky
+func (m *MRUList) IsPinned(project string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.ensureInitialized()
+
+	return m.pinned[m.normalizeProject(project)]
+}
+
+// LastOpened returns when a project was last opened, or the zero time
+// if it is unknown
+func (m *MRUList) LastOpened(project string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.ensureInitialized()
+
+	return m.opened[m.normalizeProject(project)]
+}
+
+// Pinned returns the pinned projects as relative paths
+func (m *MRUList) Pinned() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.ensureInitialized()
+
+	result := make([]string, 0, len(m.pinned))
+	for _, item := range m.items {
+		if !m.pinned[item] {
+			continue
+		}
+		if relPath := m.toRelativePath(item); relPath != "" {
+			result = append(result, relPath)
+		}
+	}
+	return result
+}
+
+// Item is an entry of the list with its flags, as moved between machines
+type Item struct {
+	Path   string    `json:"path"`
+	Pinned bool      `json:"pinned,omitempty"`
+	Opened time.Time `json:"opened"`
+}
+
+// Export returns the items of the list with absolute paths, most recent
+// first
+func (m *MRUList) Export() []Item {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.ensureInitialized()
+
+	result := make([]Item, 0, len(m.items))
+	for _, item := range m.items {
+		result = append(result, Item{Path: item, Pinned: m.pinned[item], Opened: m.opened[item]})
+	}
+	return result
+}
+
+// Import merges items into the list: projects not listed yet are added,
+// pins are kept and the later open time wins, then the list is ordered by
+// open time. Items whose project does not exist are skipped and returned.
+func (m *MRUList) Import(items []Item) (int, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ensureInitialized()
+
+	added := 0
+	var missing []string
+	for _, item := range items {
+		path := m.normalizeProject(item.Path)
+		if !m.projectExists(path) {
+			missing = append(missing, item.Path)
+			continue
+		}
+		if _, exists := m.itemSet[path]; !exists {
+			m.items = append(m.items, path)
+			m.itemSet[path] = len(m.items) - 1
+			added++
+		}
+		if item.Pinned {
+			m.pinned[path] = true
+		}
+		if item.Opened.After(m.opened[path]) {
+			m.opened[path] = item.Opened
+		}
+	}
+
+	sort.SliceStable(m.items, func(i, j int) bool {
+		return m.opened[m.items[i]].After(m.opened[m.items[j]])
+	})
+
+	m.enforceLimit()
+
+	m.dirty = true
+	return added, missing, m.saveAtomic()
+}
@@ -0,0 +1,96 @@
+package mru
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestList creates a list in a base dir holding n projects named p0..
+func newTestList(t *testing.T, n int) (*MRUList, string) {
+	t.Helper()
+	baseDir := t.TempDir()
+	for i := range n {
+		if err := os.Mkdir(filepath.Join(baseDir, fmt.Sprintf("p%d", i)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewMRUList(filepath.Join(t.TempDir(), "mru"), baseDir), baseDir
+}
+
+func countUnpinned(m *MRUList) int {
+	unpinned := 0
+	for _, item := range m.Items() {
+		if !m.IsPinned(item) {
+			unpinned++
+		}
+	}
+	return unpinned
+}
+
+func TestPinnedDoNotCountAgainstLimit(t *testing.T) {
+	m, _ := newTestList(t, maxMRUItems+10)
+	for i := range 10 {
+		if err := m.Pin(fmt.Sprintf("p%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 10; i < maxMRUItems+10; i++ {
+		if err := m.Update(fmt.Sprintf("p%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := countUnpinned(m); got != maxMRUItems {
+		t.Errorf("%d unpinned items after updates, want %d", got, maxMRUItems)
+	}
+	if got := len(m.Pinned()); got != 10 {
+		t.Errorf("%d pinned items, want 10", got)
+	}
+}
+
+func TestLoadKeepsUnpinnedLimit(t *testing.T) {
+	m, baseDir := newTestList(t, maxMRUItems+15)
+	var lines []string
+	for i := range maxMRUItems + 15 {
+		line := filepath.Join(baseDir, fmt.Sprintf("p%d", i))
+		if i < 10 {
+			line += fieldSeparator + pinnedFlag
+		}
+		lines = append(lines, line)
+	}
+	if err := os.WriteFile(m.filename, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Size(); got != maxMRUItems+10 {
+		t.Errorf("loaded %d items, want %d", got, maxMRUItems+10)
+	}
+	if got := countUnpinned(m); got != maxMRUItems {
+		t.Errorf("loaded %d unpinned items, want %d", got, maxMRUItems)
+	}
+}
+
+func TestUnpinEnforcesLimit(t *testing.T) {
+	m, _ := newTestList(t, maxMRUItems+1)
+	if err := m.Pin("p0"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= maxMRUItems; i++ {
+		if err := m.Update(fmt.Sprintf("p%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Unpin("p0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Size(); got != maxMRUItems {
+		t.Errorf("%d items after unpinning, want %d", got, maxMRUItems)
+	}
+	if m.Contains("p0") {
+		t.Errorf("unpinned oldest item is still listed")
+	}
+}
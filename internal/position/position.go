@@ -0,0 +1,152 @@
+package position
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/backup"
+)
+
+// Position is the last file and line an editor had open in a project
+type Position struct {
+	File    string    `json:"file"`
+	Line    int       `json:"line"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store persists the last position per project directory
+type Store struct {
+	filename string
+	mu       sync.Mutex
+	backups  *backup.Rotator
+}
+
+// NewStore creates a position store backed by filename
+func NewStore(filename string) *Store {
+	return &Store{filename: filename}
+}
+
+// SetBackups enables rotated backups of the positions file before each save
+func (s *Store) SetBackups(r *backup.Rotator) {
+	s.backups = r
+}
+
+// Get returns the last position recorded for a project
+func (s *Store) Get(project string) (Position, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	positions, err := s.load()
+	if err != nil {
+		return Position{}, false, err
+	}
+
+	pos, ok := positions[project]
+	return pos, ok, nil
+}
+
+// Set records the position of a project, replacing the previous one
+func (s *Store) Set(project string, pos Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	positions, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	pos.Updated = time.Now()
+	positions[project] = pos
+
+	return s.save(positions)
+}
+
+// Delete forgets the position of a project
+func (s *Store) Delete(project string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	positions, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := positions[project]; !ok {
+		return nil
+	}
+
+	delete(positions, project)
+	return s.save(positions)
+}
+
+// All returns the positions of every project
+func (s *Store) All() (map[string]Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// Merge adds positions, keeping the more recently updated one of projects
+// known to both, and returns how many were taken
+func (s *Store) Merge(positions map[string]Position) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	for project, pos := range positions {
+		if current, ok := existing[project]; ok && !pos.Updated.After(current.Updated) {
+			continue
+		}
+		existing[project] = pos
+		taken++
+	}
+	if taken == 0 {
+		return 0, nil
+	}
+	return taken, s.save(existing)
+}
+
+// load reads all positions from disk
+func (s *Store) load() (map[string]Position, error) {
+	positions := make(map[string]Position)
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return positions, nil
+		}
+		return nil, fmt.Errorf("failed to read positions file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, fmt.Errorf("failed to parse positions file: %w", err)
+	}
+	return positions, nil
+}
+
+// save atomically writes all positions to disk
+func (s *Store) save(positions map[string]Position) error {
+	data, err := json.MarshalIndent(positions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode positions: %w", err)
+	}
+
+	tempFile := s.filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write positions file: %w", err)
+	}
+	_ = s.backups.Snapshot(s.filename)
+	if err := os.Rename(tempFile, s.filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+	return nil
+}
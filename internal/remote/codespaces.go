@@ -0,0 +1,73 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"time"
+)
+
+// Codespaces lists and connects to GitHub Codespaces through the gh CLI
+type Codespaces struct{}
+
+// codespace is the subset of `gh codespace list --json` we use
+type codespace struct {
+	Name       string    `json:"name"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+}
+
+// Name returns the provider name used in labels and config
+func (c *Codespaces) Name() string {
+	return "codespaces"
+}
+
+// List returns the codespaces of the authenticated user
+func (c *Codespaces) List() ([]Workspace, error) {
+	output, err := exec.Command("gh", "codespace", "list", "--json", "name").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list codespaces: %w", err)
+	}
+
+	var entries []codespace
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse codespaces: %w", err)
+	}
+
+	workspaces := make([]Workspace, 0, len(entries))
+	for _, e := range entries {
+		workspaces = append(workspaces, Workspace{Provider: c.Name(), Name: e.Name})
+	}
+	return workspaces, nil
+}
+
+// ConnectCommand opens an SSH session in the codespace
+func (c *Codespaces) ConnectCommand(w Workspace) []string {
+	return []string{"gh", "codespace", "ssh", "-c", w.Name}
+}
+
+// ForRepo returns the codespaces of a GitHub repository ("owner/repo"),
+// the most recently used first
+func (c *Codespaces) ForRepo(repo string) ([]Workspace, error) {
+	output, err := exec.Command("gh", "codespace", "list", "--repo", repo, "--json", "name,lastUsedAt").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list codespaces of %s: %w", repo, err)
+	}
+
+	var entries []codespace
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse codespaces: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LastUsedAt.After(entries[j].LastUsedAt) })
+
+	workspaces := make([]Workspace, 0, len(entries))
+	for _, e := range entries {
+		workspaces = append(workspaces, Workspace{Provider: c.Name(), Name: e.Name})
+	}
+	return workspaces, nil
+}
+
+// CodeCommand opens the codespace in VS Code, connected remotely
+func (c *Codespaces) CodeCommand(w Workspace) []string {
+	return []string{"gh", "codespace", "code", "-c", w.Name}
+}
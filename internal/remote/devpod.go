@@ -0,0 +1,45 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// DevPod lists and connects to devpod workspaces
+type DevPod struct{}
+
+// devpodWorkspace is the subset of `devpod list --output json` we use
+type devpodWorkspace struct {
+	ID string `json:"id"`
+}
+
+// Name returns the provider name used in labels and config
+func (d *DevPod) Name() string {
+	return "devpod"
+}
+
+// List returns the workspaces known to devpod
+func (d *DevPod) List() ([]Workspace, error) {
+	output, err := exec.Command("devpod", "list", "--output", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devpod workspaces: %w", err)
+	}
+
+	var entries []devpodWorkspace
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse devpod workspaces: %w", err)
+	}
+
+	workspaces := make([]Workspace, 0, len(entries))
+	for _, e := range entries {
+		workspaces = append(workspaces, Workspace{Provider: d.Name(), Name: e.ID})
+	}
+	return workspaces, nil
+}
+
+// ConnectCommand starts the workspace if needed and opens a shell in it
+func (d *DevPod) ConnectCommand(w Workspace) []string {
+	name := shellQuote(w.Name)
+	return []string{"sh", "-c", fmt.Sprintf("devpod up %s --ide none && devpod ssh %s", name, name)}
+}
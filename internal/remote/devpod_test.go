@@ -0,0 +1,36 @@
+package remote
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDevPodConnectCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	for _, name := range []string{"api", "my project", "it's", "$(touch pwned)", "`touch pwned`", "a; rm -rf x", "new\nline"} {
+		t.Run(name, func(t *testing.T) {
+			args := (&DevPod{}).ConnectCommand(Workspace{Provider: "devpod", Name: name})
+			if len(args) != 3 || args[0] != "sh" || args[1] != "-c" {
+				t.Fatalf("args = %q, want sh -c and a script", args)
+			}
+
+			// devpod prints the words it receives instead of running
+			script := "devpod() { printf '%s\\0' \"$@\"; }; " + args[2]
+			cmd := exec.Command("sh", "-c", script)
+			cmd.Dir = t.TempDir()
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%q failed: %v", script, err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+			want := []string{"up", name, "--ide", "none", "ssh", name}
+			if !slices.Equal(got, want) {
+				t.Errorf("devpod got %q, want %q", got, want)
+			}
+		})
+	}
+}
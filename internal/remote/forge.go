@@ -0,0 +1,231 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of forges, self-hosted git services with an API listing the
+// repositories of a user
+const (
+	ForgeGitLab  = "gitlab"
+	ForgeGitea   = "gitea"
+	ForgeForgejo = "forgejo" // Speaks the Gitea API
+)
+
+const (
+	forgeTimeout  = 10 * time.Second // Budget of listing all pages
+	forgePageSize = 50
+	forgeMaxPages = 20 // At most this many pages are listed
+)
+
+// defaultForgeURLs are the instances of forges configured without a URL
+var defaultForgeURLs = map[string]string{
+	ForgeGitLab:  "https://gitlab.com",
+	ForgeGitea:   "https://gitea.com",
+	ForgeForgejo: "https://codeberg.org",
+}
+
+// Cloner is implemented by providers whose workspaces are hosted
+// repositories, cloned into the base dir when selected rather than
+// connected to
+type Cloner interface {
+	// CloneURL returns the URL git clones the repository of w from
+	CloneURL(w Workspace) string
+}
+
+// Forge lists the repositories of a GitLab or Gitea/Forgejo instance that
+// the owner of a token is a member of
+type Forge struct {
+	kind    string
+	name    string
+	baseURL string
+	token   string
+	https   bool // Clone over HTTPS rather than SSH
+	client  *http.Client
+
+	mu        sync.Mutex
+	cloneURLs map[string]string // Clone URLs of the listed repositories
+}
+
+// NewForge creates a provider for a forge of a kind, labelled name. An
+// empty baseURL means the public instance of the kind.
+func NewForge(kind, name, baseURL, token string, https bool) (*Forge, error) {
+	if _, ok := defaultForgeURLs[kind]; !ok {
+		return nil, fmt.Errorf("unknown forge type %q (want %s, %s or %s)", kind, ForgeGitLab, ForgeGitea, ForgeForgejo)
+	}
+	if name == "" {
+		name = kind
+	}
+	if baseURL == "" {
+		baseURL = defaultForgeURLs[kind]
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid %s URL %q: %w", name, baseURL, err)
+	}
+
+	return &Forge{
+		kind:      kind,
+		name:      name,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		token:     token,
+		https:     https,
+		client:    &http.Client{Timeout: forgeTimeout},
+		cloneURLs: make(map[string]string),
+	}, nil
+}
+
+// Name returns the provider name used in labels and config
+func (f *Forge) Name() string {
+	return f.name
+}
+
+// forgeRepo is a repository with the URLs it can be cloned from
+type forgeRepo struct {
+	path     string // e.g. "group/subgroup/repo"
+	sshURL   string
+	httpsURL string
+}
+
+// List returns the repositories the token's owner is a member of
+func (f *Forge) List() ([]Workspace, error) {
+	if f.token == "" {
+		return nil, fmt.Errorf("no token for %s", f.name)
+	}
+
+	var repos []forgeRepo
+	var err error
+	if f.kind == ForgeGitLab {
+		repos, err = f.listGitLab()
+	} else {
+		repos, err = f.listGitea()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s repositories: %w", f.name, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	workspaces := make([]Workspace, 0, len(repos))
+	for _, r := range repos {
+		f.cloneURLs[r.path] = r.sshURL
+		if f.https || r.sshURL == "" {
+			f.cloneURLs[r.path] = r.httpsURL
+		}
+		workspaces = append(workspaces, Workspace{Provider: f.name, Name: r.path})
+	}
+	return workspaces, nil
+}
+
+// listGitLab lists projects through the GitLab v4 API, following its
+// next page header
+func (f *Forge) listGitLab() ([]forgeRepo, error) {
+	var repos []forgeRepo
+	page := "1"
+	for n := 0; page != "" && n < forgeMaxPages; n++ {
+		query := url.Values{
+			"membership": {"true"},
+			"simple":     {"true"},
+			"archived":   {"false"},
+			"per_page":   {strconv.Itoa(forgePageSize)},
+			"page":       {page},
+		}
+		var entries []struct {
+			Path     string `json:"path_with_namespace"`
+			SSHURL   string `json:"ssh_url_to_repo"`
+			HTTPSURL string `json:"http_url_to_repo"`
+		}
+		header, err := f.get("/api/v4/projects?"+query.Encode(), "PRIVATE-TOKEN", f.token, &entries)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			repos = append(repos, forgeRepo{path: e.Path, sshURL: e.SSHURL, httpsURL: e.HTTPSURL})
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return repos, nil
+}
+
+// listGitea lists repositories through the Gitea v1 API, which Forgejo
+// serves as well, until a page comes back short
+func (f *Forge) listGitea() ([]forgeRepo, error) {
+	var repos []forgeRepo
+	for page := 1; page <= forgeMaxPages; page++ {
+		query := url.Values{
+			"limit": {strconv.Itoa(forgePageSize)},
+			"page":  {strconv.Itoa(page)},
+		}
+		var entries []struct {
+			FullName string `json:"full_name"`
+			SSHURL   string `json:"ssh_url"`
+			CloneURL string `json:"clone_url"`
+			Archived bool   `json:"archived"`
+		}
+		if _, err := f.get("/api/v1/user/repos?"+query.Encode(), "Authorization", "token "+f.token, &entries); err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.Archived {
+				repos = append(repos, forgeRepo{path: e.FullName, sshURL: e.SSHURL, httpsURL: e.CloneURL})
+			}
+		}
+		if len(entries) < forgePageSize {
+			break
+		}
+	}
+	return repos, nil
+}
+
+// get decodes the JSON response to an authenticated API request into v
+func (f *Forge) get(path, authHeader, auth string, v any) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, f.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(authHeader, auth)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", f.baseURL, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// CloneURL returns the URL of a listed repository, or the usual one of
+// the instance for repositories not listed in this run
+func (f *Forge) CloneURL(w Workspace) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cloneURL, ok := f.cloneURLs[w.Name]; ok {
+		return cloneURL
+	}
+	if f.https {
+		return f.baseURL + "/" + w.Name + ".git"
+	}
+	host := f.baseURL
+	if u, err := url.Parse(f.baseURL); err == nil {
+		host = u.Hostname()
+	}
+	return "git@" + host + ":" + w.Name + ".git"
+}
+
+// ConnectCommand returns nil, repositories are cloned rather than
+// connected to, see Cloner
+func (f *Forge) ConnectCommand(w Workspace) []string {
+	return nil
+}
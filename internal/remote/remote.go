@@ -0,0 +1,106 @@
+package remote
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// labelSeparator separates the provider from the workspace name in labels
+const labelSeparator = ":"
+
+// Workspace is a remote development environment listed next to local projects
+type Workspace struct {
+	Provider string
+	Name     string
+}
+
+// Label returns the entry shown in the project list, e.g. "devpod:api"
+func (w Workspace) Label() string {
+	return w.Provider + labelSeparator + w.Name
+}
+
+// Provider lists remote workspaces and knows how to connect to them
+type Provider interface {
+	Name() string
+	List() ([]Workspace, error)
+	ConnectCommand(w Workspace) []string
+}
+
+// providers maps provider names to their constructors
+var providers = map[string]func() Provider{
+	"devpod":     func() Provider { return &DevPod{} },
+	"codespaces": func() Provider { return &Codespaces{} },
+}
+
+// Registry holds the enabled remote providers
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates a registry with the named providers enabled
+func NewRegistry(names []string) (*Registry, error) {
+	r := &Registry{providers: make(map[string]Provider, len(names))}
+	for _, name := range names {
+		newProvider, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown remote provider: %s", name)
+		}
+		p := newProvider()
+		r.providers[p.Name()] = p
+	}
+	return r, nil
+}
+
+// Add enables a provider that needs settings of its own
+func (r *Registry) Add(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+// Workspaces lists workspaces from every provider concurrently, sorted by
+// provider and then name so the list is the same on every run. Providers
+// that fail (missing CLI, not logged in) are skipped.
+func (r *Registry) Workspaces() []Workspace {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result []Workspace
+	)
+
+	for _, p := range r.providers {
+		wg.Add(1)
+		go func(p Provider) {
+			defer wg.Done()
+			workspaces, err := p.List()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			result = append(result, workspaces...)
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	slices.SortFunc(result, func(a, b Workspace) int {
+		return cmp.Or(cmp.Compare(a.Provider, b.Provider), cmp.Compare(a.Name, b.Name))
+	})
+	return result
+}
+
+// Lookup resolves a project list label into a workspace and its provider
+func (r *Registry) Lookup(label string) (Workspace, Provider, bool) {
+	providerName, name, found := strings.Cut(label, labelSeparator)
+	if !found || name == "" {
+		return Workspace{}, nil, false
+	}
+
+	p, ok := r.providers[providerName]
+	if !ok {
+		return Workspace{}, nil, false
+	}
+
+	return Workspace{Provider: providerName, Name: name}, p, true
+}
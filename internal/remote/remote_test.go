@@ -0,0 +1,45 @@
+package remote
+
+import (
+	"slices"
+	"testing"
+)
+
+// fakeProvider lists fixed workspaces
+type fakeProvider struct {
+	name  string
+	names []string
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) List() ([]Workspace, error) {
+	workspaces := make([]Workspace, 0, len(f.names))
+	for _, name := range f.names {
+		workspaces = append(workspaces, Workspace{Provider: f.name, Name: name})
+	}
+	return workspaces, nil
+}
+
+func (f *fakeProvider) ConnectCommand(w Workspace) []string { return nil }
+
+func TestWorkspacesSorted(t *testing.T) {
+	r, err := NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Add(&fakeProvider{name: "ssh", names: []string{"box:/srv/b", "box:/srv/a"}})
+	r.Add(&fakeProvider{name: "gitlab", names: []string{"group/z", "group/a"}})
+	r.Add(&fakeProvider{name: "devpod", names: []string{"web", "api"}})
+
+	want := []string{"devpod:api", "devpod:web", "gitlab:group/a", "gitlab:group/z", "ssh:box:/srv/a", "ssh:box:/srv/b"}
+	for range 20 {
+		var got []string
+		for _, w := range r.Workspaces() {
+			got = append(got, w.Label())
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Workspaces() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SSH opens projects on other machines in a tmux session over SSH
+type SSH struct {
+	projects []Workspace
+}
+
+// NewSSH creates a provider for projects given as "host:/path/to/project"
+func NewSSH(targets []string) (*SSH, error) {
+	s := &SSH{}
+	for _, target := range targets {
+		host, dir, found := strings.Cut(target, ":")
+		if !found || host == "" || dir == "" {
+			return nil, fmt.Errorf("invalid ssh project %q, expected host:/path", target)
+		}
+		s.projects = append(s.projects, Workspace{Provider: s.Name(), Name: target})
+	}
+	return s, nil
+}
+
+// Name returns the provider name used in labels and config
+func (s *SSH) Name() string {
+	return "ssh"
+}
+
+// List returns the configured projects
+func (s *SSH) List() ([]Workspace, error) {
+	return s.projects, nil
+}
+
+// ConnectCommand attaches to the tmux session of the project on its host,
+// starting Neovim in a new one
+func (s *SSH) ConnectCommand(w Workspace) []string {
+	host, dir, _ := strings.Cut(w.Name, ":")
+	dir = strings.TrimSuffix(dir, "/")
+	session := shellQuote(sessionName(path.Base(dir)))
+	remoteDir := remotePath(dir)
+
+	// The remote shell parses the command, so every word in it is quoted
+	return []string{"ssh", "-t", "--", host, fmt.Sprintf("tmux new -A -s %s -c %s nvim %s", session, remoteDir, remoteDir)}
+}
+
+// sessionName replaces the characters tmux does not allow in session names
+func sessionName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// remotePath quotes a path for the remote shell, leaving a leading ~/ to
+// be expanded there
+func remotePath(dir string) string {
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		return "~/" + shellQuote(rest)
+	}
+	return shellQuote(dir)
+}
+
+// shellQuote wraps s in single quotes for the shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSSHConnectCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	tests := []struct {
+		name    string
+		target  string
+		dir     string // As the remote shell sees it
+		session string
+	}{
+		{"plain", "box:/srv/api", "/srv/api", "api"},
+		{"trailing slash", "box:/srv/api/", "/srv/api", "api"},
+		{"space", "box:/srv/my project", "/srv/my project", "my_project"},
+		{"single quote", "box:/srv/it's", "/srv/it's", "it_s"},
+		{"command substitution", "box:/srv/$(touch pwned)", "/srv/$(touch pwned)", "__touch_pwned_"},
+		{"backticks", "box:/srv/`touch pwned`", "/srv/`touch pwned`", "_touch_pwned_"},
+		{"separators", "box:/srv/a; rm -rf x", "/srv/a; rm -rf x", "a__rm__rf_x"},
+		{"newline", "box:/srv/new\nline", "/srv/new\nline", "new_line"},
+		{"home", "box:~/it's", "HOME/it's", "it_s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSSH([]string{tt.target})
+			if err != nil {
+				t.Fatalf("NewSSH: %v", err)
+			}
+			args := s.ConnectCommand(Workspace{Provider: "ssh", Name: tt.target})
+			if want := []string{"ssh", "-t", "--", "box"}; !slices.Equal(args[:len(args)-1], want) {
+				t.Fatalf("args = %q, want %q followed by the command", args, want)
+			}
+
+			// Run the remote command through a local shell, with tmux
+			// printing the words it receives
+			script := "tmux() { printf '%s\\0' \"$@\"; }; " + args[len(args)-1]
+			cmd := exec.Command("sh", "-c", script)
+			cmd.Dir = t.TempDir()
+			cmd.Env = []string{"HOME=HOME"}
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%q failed: %v", script, err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+			want := []string{"new", "-A", "-s", tt.session, "-c", tt.dir, "nvim", tt.dir}
+			if !slices.Equal(got, want) {
+				t.Errorf("tmux got %q, want %q", got, want)
+			}
+		})
+	}
+}
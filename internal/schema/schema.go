@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema version of generated documents
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern matches Go durations such as "10s" or "1m30s"
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// Schema is a node of a JSON Schema document
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// Generator builds schemas from config structs
+type Generator struct {
+	// Tag names the struct tag holding the keys, e.g. yaml or mapstructure
+	Tag string
+	// Enums lists the accepted values of keys by dotted path, "*" standing
+	// for any map key or list index, e.g. "editors.*". A struct whose key
+	// has values listed may also be given as one of them, like a preset.
+	Enums map[string][]string
+}
+
+// Generate returns the schema document of the type of v
+func (g *Generator) Generate(title string, v any) *Schema {
+	s := g.For(v)
+	s.Schema = Draft
+	s.Title = title
+	return s
+}
+
+// For returns the schema of the type of v, to be nested in a document
+func (g *Generator) For(v any) *Schema {
+	return g.schema(reflect.TypeOf(v), "")
+}
+
+// schema returns the schema of a value of type t found at path
+func (g *Generator) schema(t reflect.Type, path string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	enum := g.Enums[path]
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &Schema{AnyOf: []*Schema{{Type: "string", Pattern: durationPattern}, {Type: "integer"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string", Enum: enum}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), join(path, "*"))}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), join(path, "*"))}
+	case reflect.Struct:
+		s := g.object(t, path)
+		if len(enum) > 0 {
+			return &Schema{AnyOf: []*Schema{{Type: "string", Enum: enum}, s}}
+		}
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+// object returns the schema of a struct, keyed by the generator tag
+func (g *Generator) object(t reflect.Type, path string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get(g.Tag), ",")
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		s.Properties[key] = g.schema(field.Type, join(path, key))
+	}
+	return s
+}
+
+// join appends a key to a dotted path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Has reports whether a dotted key path such as "format.path_style" names
+// a setting of the schema
+func (s *Schema) Has(path string) bool {
+	node := s
+	for _, key := range strings.Split(path, ".") {
+		node = node.child(key)
+		if node == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// child returns the schema of a key of an object, or nil
+func (s *Schema) child(key string) *Schema {
+	if property, ok := s.Properties[key]; ok {
+		return property
+	}
+	if additional, ok := s.AdditionalProperties.(*Schema); ok {
+		return additional
+	}
+	for _, alternative := range s.AnyOf {
+		if child := alternative.child(key); child != nil {
+			return child
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxFileSize is the size above which the built-in search skips files
+const maxFileSize = 4 << 20
+
+// Match is a line of a file matching the pattern
+type Match struct {
+	File string `json:"file"` // Relative to the searched directory
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Options tune a search
+type Options struct {
+	Pattern    string
+	IgnoreCase bool
+	// SkipDirs names directories the built-in search does not enter,
+	// globs allowed, besides hidden ones; ripgrep follows .gitignore instead
+	SkipDirs []string
+	// BuiltIn searches without ripgrep even when it is installed
+	BuiltIn bool
+}
+
+// Searcher searches directories for a pattern
+type Searcher struct {
+	opts    Options
+	re      *regexp.Regexp
+	ripgrep string // Path of rg, empty to search built in
+}
+
+// New compiles the pattern and looks up ripgrep
+func New(opts Options) (*Searcher, error) {
+	pattern := opts.Pattern
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	s := &Searcher{opts: opts, re: re}
+	if !opts.BuiltIn {
+		s.ripgrep, _ = exec.LookPath("rg")
+	}
+	return s, nil
+}
+
+// Engine names what searches: rg or built-in
+func (s *Searcher) Engine() string {
+	if s.ripgrep != "" {
+		return "rg"
+	}
+	return "built-in"
+}
+
+// Dir returns the matches in the files under dir, ordered by file and line
+func (s *Searcher) Dir(dir string) ([]Match, error) {
+	if s.ripgrep != "" {
+		return s.runRipgrep(dir)
+	}
+	return s.walk(dir)
+}
+
+// runRipgrep searches dir with rg, which skips ignored and binary files
+func (s *Searcher) runRipgrep(dir string) ([]Match, error) {
+	args := []string{"--line-number", "--with-filename", "--no-heading", "--null", "--color", "never", "--sort", "path"}
+	if s.opts.IgnoreCase {
+		args = append(args, "--ignore-case")
+	}
+	cmd := exec.Command(s.ripgrep, append(args, "--regexp", s.opts.Pattern)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil // Nothing matched
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var matches []Match
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		file, rest, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		number, text, _ := strings.Cut(rest, ":")
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, Match{File: filepath.Clean(file), Line: n, Text: text})
+	}
+	return matches, nil
+}
+
+// walk searches the text files under dir, skipping hidden and skipped
+// directories
+func (s *Searcher) walk(dir string) ([]Match, error) {
+	var matches []Match
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are left out
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || s.skipped(name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+
+		found, err := s.file(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		for _, m := range found {
+			m.File = rel
+			matches = append(matches, m)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// skipped reports whether a directory name matches one of SkipDirs
+func (s *Searcher) skipped(name string) bool {
+	for _, pattern := range s.opts.SkipDirs {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// file returns the matching lines of a file, none for binary files
+func (s *Searcher) file(path string) ([]Match, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	if head, _ := reader.Peek(8000); bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var matches []Match
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			return matches, nil
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if s.re.MatchString(line) {
+			matches = append(matches, Match{Line: n, Text: line})
+		}
+		if err == io.EOF {
+			return matches, nil
+		}
+		if err != nil {
+			return matches, err
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/marianozunino/code/v2/internal/backup"
+)
+
+// Counters are running totals of project scans and annotation cache
+// lookups, kept between runs to report averages
+type Counters struct {
+	Scans       int           `json:"scans"`
+	ScanTime    time.Duration `json:"scan_time"`
+	CacheHits   int           `json:"cache_hits"`
+	CacheMisses int           `json:"cache_misses"`
+}
+
+// AverageScan returns the mean duration of a scan
+func (c Counters) AverageScan() time.Duration {
+	if c.Scans == 0 {
+		return 0
+	}
+	return c.ScanTime / time.Duration(c.Scans)
+}
+
+// HitRate returns the share of cache lookups that found a value, from 0
+// to 1
+func (c Counters) HitRate() float64 {
+	lookups := c.CacheHits + c.CacheMisses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(c.CacheHits) / float64(lookups)
+}
+
+// DefaultFile returns the counters location under the XDG state dir
+func DefaultFile() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "code", "stats.json")
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "code", "stats.json")
+}
+
+// Load reads the counters from filename. A missing file holds no counts.
+func Load(filename string) (Counters, error) {
+	var counters Counters
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return counters, nil
+		}
+		return counters, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return counters, fmt.Errorf("failed to parse stats file: %w", err)
+	}
+	return counters, nil
+}
+
+// Add adds delta to the counters in filename, backed up by backups when
+// not nil. Unreadable counters start over rather than stopping the count.
+func Add(filename string, delta Counters, backups *backup.Rotator) error {
+	counters, _ := Load(filename)
+	counters.Scans += delta.Scans
+	counters.ScanTime += delta.ScanTime
+	counters.CacheHits += delta.CacheHits
+	counters.CacheMisses += delta.CacheMisses
+
+	data, err := json.Marshal(counters)
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create stats dir: %w", err)
+	}
+
+	tempFile := filename + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	_ = backups.Snapshot(filename)
+	if err := os.Rename(tempFile, filename); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("atomic rename failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/marianozunino/code/v2/internal/fuzzy"
+)
+
+// Numbered prints items as a numbered list on out and reads the chosen
+// numbers from in, for terminals the finder cannot draw on. Any other
+// answer filters the list, picking the project right away when only one
+// matches; an empty answer cancels.
+func Numbered(prompt string, items []string, in io.Reader, out io.Writer) (Result, error) {
+	reader := bufio.NewReader(in)
+	shown := items
+	for {
+		width := len(strconv.Itoa(len(shown)))
+		for i, item := range shown {
+			fmt.Fprintf(out, "%*d) %s\n", width, i+1, item)
+		}
+		fmt.Fprintf(out, "%s(numbers, text to filter, Enter to cancel) ", prompt)
+
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return Result{}, err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			return Result{}, nil
+		}
+
+		if chosen, ok := pickNumbers(answer, shown); ok {
+			return Result{Items: chosen}, nil
+		}
+
+		var matches []string
+		for _, m := range fuzzy.Filter(answer, items) {
+			matches = append(matches, m.Str)
+		}
+		switch len(matches) {
+		case 0:
+			fmt.Fprintf(out, "Nothing matches %q\n", answer)
+		case 1:
+			return Result{Items: matches}, nil
+		default:
+			shown = matches
+		}
+		if err == io.EOF {
+			return Result{}, nil
+		}
+	}
+}
+
+// pickNumbers returns the items of an answer such as "2" or "1, 3", or
+// false when it is not made of numbers of the list
+func pickNumbers(answer string, items []string) ([]string, bool) {
+	fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+	chosen := make([]string, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(items) {
+			return nil, false
+		}
+		chosen = append(chosen, items[n-1])
+	}
+	return chosen, true
+}
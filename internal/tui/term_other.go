@@ -0,0 +1,24 @@
+//go:build !linux
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw fails, raw mode is only implemented for Linux terminals
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw mode is only supported on Linux")
+}
+
+// size returns 80x24, the terminal size is only read on Linux
+func (f *Finder) size() (int, int) {
+	return 80, 24
+}
+
+// IsTerminal reports whether f is a character device, which terminals are
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,393 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/marianozunino/code/v2/internal/fuzzy"
+)
+
+// ErrNoTerminal is returned when there is no terminal to draw the finder on
+var ErrNoTerminal = errors.New("no terminal available")
+
+// Terminal escape sequences used by the finder
+const (
+	altScreenOn  = "\x1b[?1049h"
+	altScreenOff = "\x1b[?1049l"
+	clearScreen  = "\x1b[H\x1b[2J"
+	bold         = "\x1b[1m"
+	reverse      = "\x1b[7m"
+	reset        = "\x1b[0m"
+)
+
+// Update replaces the item at Index while the finder is open
+type Update struct {
+	Index int
+	Item  string
+}
+
+// Options configures the finder
+type Options struct {
+	Updates <-chan Update // Replaces items in place while the finder is open, may be nil
+	Expect  []string      // Keys such as "ctrl-d" that accept the selection like Enter
+	Accent  string        // "#rrggbb" color of the prompt and highlighted item, empty for none
+	Match   string        // "#rrggbb" color of matched characters, empty for none
+}
+
+// Result is what the user chose in the finder
+type Result struct {
+	Key   string   // The expected key that accepted the selection, empty for Enter
+	Items []string // The chosen items, empty when the user cancelled
+}
+
+// Finder is an interactive fuzzy finder drawn on the controlling terminal
+type Finder struct {
+	prompt  string
+	items   []string
+	query   []rune
+	matches []fuzzy.Match
+	cursor  int
+	offset  int
+	marked  map[int]bool      // Indexes of items selected with Tab
+	expect  map[string]string // Key sequences that accept the selection, by key name
+	accent  string            // Escape sequences of the theme colors, empty without
+	match   string
+	tty     *os.File
+	out     *bufio.Writer
+}
+
+// Run shows items on the terminal and returns the chosen ones.
+// Items marked with Tab are returned in list order; without marks the
+// highlighted item is returned. It returns no items when the user cancels.
+func Run(prompt string, items []string, opts Options) (Result, error) {
+	expect := make(map[string]string, len(opts.Expect))
+	for _, name := range opts.Expect {
+		sequence, ok := keySequence(name)
+		if !ok {
+			return Result{}, fmt.Errorf("unsupported key: %s", name)
+		}
+		expect[sequence] = name
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrNoTerminal, err)
+	}
+	defer tty.Close()
+
+	restore, err := makeRaw(int(tty.Fd()))
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrNoTerminal, err)
+	}
+	defer restore()
+
+	f := &Finder{
+		prompt: prompt,
+		items:  items,
+		marked: make(map[int]bool),
+		expect: expect,
+		accent: foreground(opts.Accent),
+		match:  foreground(opts.Match),
+		tty:    tty,
+		out:    bufio.NewWriter(tty),
+	}
+	f.out.WriteString(altScreenOn)
+	defer func() {
+		f.out.WriteString(altScreenOff)
+		f.out.Flush()
+	}()
+
+	return f.loop(opts.Updates)
+}
+
+// foreground returns the escape sequence setting a "#rrggbb" text color,
+// or nothing for other values and when NO_COLOR is set
+func foreground(color string) string {
+	var red, green, blue uint8
+	if os.Getenv("NO_COLOR") != "" || len(color) != 7 {
+		return ""
+	}
+	if _, err := fmt.Sscanf(color, "#%02x%02x%02x", &red, &green, &blue); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", red, green, blue)
+}
+
+// keySequence returns the bytes a terminal sends for a key name.
+// Only ctrl-a to ctrl-z are supported.
+func keySequence(name string) (string, bool) {
+	letter, ok := strings.CutPrefix(name, "ctrl-")
+	if !ok || len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		return "", false
+	}
+	return string(rune(letter[0] - 'a' + 1)), true
+}
+
+// loop reads keys and redraws until an item is chosen or the user cancels
+func (f *Finder) loop(updates <-chan Update) (Result, error) {
+	f.refilter()
+
+	keys := make(chan []byte)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go f.readKeys(keys, errs, done)
+
+	for {
+		f.draw()
+
+		var input []byte
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil // All updates delivered
+				continue
+			}
+			f.replace(update)
+			continue
+		case err := <-errs:
+			return Result{}, fmt.Errorf("failed to read from terminal: %w", err)
+		case input = <-keys:
+		}
+
+		// Expected keys take precedence over the built-in bindings
+		if name, ok := f.expect[string(input)]; ok {
+			if selected := f.selection(); len(selected) > 0 {
+				return Result{Key: name, Items: selected}, nil
+			}
+			continue
+		}
+
+		switch key := string(input); key {
+		case "\r":
+			if selected := f.selection(); len(selected) > 0 {
+				return Result{Items: selected}, nil
+			}
+		case "\x1b", "\x03", "\x07": // Esc, Ctrl-C, Ctrl-G
+			return Result{}, nil
+		case "\t": // Tab toggles the mark and moves down
+			if len(f.matches) > 0 {
+				index := f.matches[f.cursor].Index
+				f.marked[index] = !f.marked[index]
+				f.move(1)
+			}
+		case "\x1b[A", "\x1bOA", "\x10", "\x0b": // Up, Ctrl-P, Ctrl-K
+			f.move(-1)
+		case "\x1b[B", "\x1bOB", "\x0e", "\n": // Down, Ctrl-N, Ctrl-J
+			f.move(1)
+		case "\x1b[5~": // Page up
+			f.move(-f.pageSize())
+		case "\x1b[6~": // Page down
+			f.move(f.pageSize())
+		case "\x7f", "\x08": // Backspace
+			if len(f.query) > 0 {
+				f.query = f.query[:len(f.query)-1]
+				f.refilter()
+			}
+		case "\x15": // Ctrl-U
+			f.query = f.query[:0]
+			f.refilter()
+		case "\x17": // Ctrl-W
+			f.deleteWord()
+			f.refilter()
+		default:
+			if f.insert(input) {
+				f.refilter()
+			}
+		}
+	}
+}
+
+// readKeys forwards terminal input to keys until done is closed
+func (f *Finder) readKeys(keys chan<- []byte, errs chan<- error, done <-chan struct{}) {
+	buf := make([]byte, 64)
+	for {
+		n, err := f.tty.Read(buf)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		select {
+		case keys <- append([]byte(nil), buf[:n]...):
+		case <-done:
+			return
+		}
+	}
+}
+
+// replace swaps an item for its updated version, keeping the cursor on
+// the highlighted item
+func (f *Finder) replace(update Update) {
+	if update.Index < 0 || update.Index >= len(f.items) {
+		return
+	}
+
+	current := -1
+	if len(f.matches) > 0 {
+		current = f.matches[f.cursor].Index
+	}
+
+	f.items[update.Index] = update.Item
+	f.matches = fuzzy.Filter(string(f.query), f.items)
+	f.cursor = 0
+	for i, m := range f.matches {
+		if m.Index == current {
+			f.cursor = i
+			break
+		}
+	}
+	f.move(0)
+}
+
+// selection returns the marked items, or the highlighted one if none are marked
+func (f *Finder) selection() []string {
+	var selected []string
+	for i, item := range f.items {
+		if f.marked[i] {
+			selected = append(selected, item)
+		}
+	}
+	if len(selected) == 0 && len(f.matches) > 0 {
+		selected = append(selected, f.matches[f.cursor].Str)
+	}
+	return selected
+}
+
+// insert appends the printable runes of input to the query
+func (f *Finder) insert(input []byte) bool {
+	if len(input) > 0 && input[0] == 0x1b {
+		return false // Unknown escape sequence
+	}
+
+	changed := false
+	for len(input) > 0 {
+		r, size := utf8.DecodeRune(input)
+		input = input[size:]
+		if r != utf8.RuneError && unicode.IsPrint(r) {
+			f.query = append(f.query, r)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// markedCount returns the number of items marked with Tab
+func (f *Finder) markedCount() int {
+	count := 0
+	for _, marked := range f.marked {
+		if marked {
+			count++
+		}
+	}
+	return count
+}
+
+// deleteWord removes the last word of the query
+func (f *Finder) deleteWord() {
+	i := len(f.query)
+	for i > 0 && f.query[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && f.query[i-1] != ' ' {
+		i--
+	}
+	f.query = f.query[:i]
+}
+
+// refilter recomputes the matches for the current query
+func (f *Finder) refilter() {
+	f.matches = fuzzy.Filter(string(f.query), f.items)
+	f.cursor = 0
+	f.offset = 0
+}
+
+// pageSize returns the number of item rows visible on screen
+func (f *Finder) pageSize() int {
+	_, height := f.size()
+	if height <= 2 {
+		return 1
+	}
+	return height - 2
+}
+
+// move shifts the cursor by delta, keeping it on screen
+func (f *Finder) move(delta int) {
+	if len(f.matches) == 0 {
+		return
+	}
+
+	f.cursor = max(0, min(len(f.matches)-1, f.cursor+delta))
+
+	page := f.pageSize()
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	} else if f.cursor >= f.offset+page {
+		f.offset = f.cursor - page + 1
+	}
+}
+
+// draw renders the prompt, the match counter and the visible matches
+func (f *Finder) draw() {
+	width, _ := f.size()
+
+	f.out.WriteString(clearScreen)
+	f.out.WriteString(f.accent + f.prompt + reset + string(f.query) + "\r\n")
+	fmt.Fprintf(f.out, "  %d/%d", len(f.matches), len(f.items))
+	if marked := f.markedCount(); marked > 0 {
+		fmt.Fprintf(f.out, " (%d)", marked)
+	}
+	f.out.WriteString("\r\n")
+
+	end := min(len(f.matches), f.offset+f.pageSize())
+	for i := f.offset; i < end; i++ {
+		f.drawMatch(f.matches[i], i == f.cursor, width)
+		if i < end-1 {
+			f.out.WriteString("\r\n")
+		}
+	}
+
+	// Park the cursor at the end of the query
+	fmt.Fprintf(f.out, "\x1b[1;%dH", utf8.RuneCountInString(f.prompt)+len(f.query)+1)
+	f.out.Flush()
+}
+
+// drawMatch renders a single match with its matched characters in bold
+func (f *Finder) drawMatch(m fuzzy.Match, selected bool, width int) {
+	marker := "  "
+	if f.marked[m.Index] {
+		marker = " *"
+	}
+	rowStyle := ""
+	if selected {
+		marker = ">" + marker[1:]
+		rowStyle = reverse + f.accent
+		f.out.WriteString(rowStyle)
+	}
+	f.out.WriteString(marker)
+
+	matched := make(map[int]bool, len(m.Positions))
+	for _, p := range m.Positions {
+		matched[p] = true
+	}
+
+	var line strings.Builder
+	for i, r := range []rune(m.Str) {
+		if i >= width-len(marker) {
+			break
+		}
+		if matched[i] {
+			line.WriteString(bold + f.match + string(r) + reset + rowStyle)
+			continue
+		}
+		line.WriteRune(r)
+	}
+
+	f.out.WriteString(line.String())
+	f.out.WriteString(reset)
+}
@@ -0,0 +1,226 @@
+package window
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Hyprland talks to Hyprland through hyprctl and its event socket
+type Hyprland struct{}
+
+// HyprlandClient is a window as listed by `hyprctl clients -j`
+type HyprlandClient struct {
+	Address string `json:"address"` // Hex, e.g. "0x55d1c2a3b4c0"
+	Title   string `json:"title"`
+	Class   string `json:"class"`
+	PID     int    `json:"pid"`
+}
+
+// Name identifies the backend
+func (h *Hyprland) Name() string {
+	return "hyprland"
+}
+
+// Available reports whether a Hyprland session and hyprctl are present
+func (h *Hyprland) Available() bool {
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" {
+		return false
+	}
+	_, err := exec.LookPath("hyprctl")
+	return err == nil
+}
+
+// Capabilities returns what hyprctl and the event socket support
+func (h *Hyprland) Capabilities() Capability {
+	return CanFind | CanFocus | CanSubscribe
+}
+
+// clients lists the open windows
+func (h *Hyprland) clients() ([]HyprlandClient, error) {
+	output, err := exec.Command("hyprctl", "clients", "-j").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hyprland clients: %w", err)
+	}
+
+	var clients []HyprlandClient
+	if err := json.Unmarshal(output, &clients); err != nil {
+		return nil, fmt.Errorf("failed to parse hyprland clients: %w", err)
+	}
+	return clients, nil
+}
+
+// find returns the ID of the first window match accepts
+func (h *Hyprland) find(match func(HyprlandClient) bool) (int64, error) {
+	clients, err := h.clients()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range clients {
+		if match(c) {
+			return parseHyprlandAddress(c.Address), nil
+		}
+	}
+	return 0, nil
+}
+
+// FindWindow finds a window by title
+func (h *Hyprland) FindWindow(title string) (int64, error) {
+	return h.find(func(c HyprlandClient) bool { return c.Title == title })
+}
+
+// FindAppWindow finds a window of an application by the project name in
+// its title
+func (h *Hyprland) FindAppWindow(app, project string) (int64, error) {
+	return h.find(func(c HyprlandClient) bool {
+		return strings.EqualFold(c.Class, app) && TitleNamesProject(c.Title, project)
+	})
+}
+
+// FindWindowByPID finds the window owned by a process
+func (h *Hyprland) FindWindowByPID(pid int) (int64, error) {
+	return h.find(func(c HyprlandClient) bool { return c.PID == pid })
+}
+
+// WindowTitles returns the titles of all windows
+func (h *Hyprland) WindowTitles() ([]string, error) {
+	clients, err := h.clients()
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, 0, len(clients))
+	for _, c := range clients {
+		titles = append(titles, c.Title)
+	}
+	return titles, nil
+}
+
+// FocusWindow focuses a window by ID
+func (h *Hyprland) FocusWindow(windowID int64) error {
+	return h.dispatch("focuswindow", hyprlandWindow(windowID))
+}
+
+// CloseWindow asks a window to close
+func (h *Hyprland) CloseWindow(windowID int64) error {
+	return h.dispatch("closewindow", hyprlandWindow(windowID))
+}
+
+// MoveToWorkspace moves a window to a workspace by ID or "name:...",
+// or to the active one, without following it
+func (h *Hyprland) MoveToWorkspace(windowID int64, workspace string) error {
+	if workspace == "" {
+		output, err := exec.Command("hyprctl", "activeworkspace", "-j").Output()
+		if err != nil {
+			return fmt.Errorf("failed to get the active hyprland workspace: %w", err)
+		}
+		var active struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(output, &active); err != nil {
+			return fmt.Errorf("failed to parse the active hyprland workspace: %w", err)
+		}
+		workspace = strconv.Itoa(active.ID)
+	}
+
+	return h.dispatch("movetoworkspacesilent", workspace+","+hyprlandWindow(windowID))
+}
+
+// RunCommand runs a dispatcher with its argument, e.g. "fullscreen 1".
+// Dispatchers act on the active window, which is the window once focused.
+func (h *Hyprland) RunCommand(windowID int64, command string) error {
+	dispatcher, arg, _ := strings.Cut(command, " ")
+	return h.dispatch(dispatcher, arg)
+}
+
+// hyprlandWindow returns the dispatcher argument selecting a window by ID
+func hyprlandWindow(windowID int64) string {
+	return fmt.Sprintf("address:0x%x", windowID)
+}
+
+// dispatch runs a hyprctl dispatcher
+func (h *Hyprland) dispatch(dispatcher, arg string) error {
+	output, err := exec.Command("hyprctl", "dispatch", dispatcher, arg).Output()
+	if err != nil {
+		return fmt.Errorf("hyprctl dispatch %s failed: %w", dispatcher, err)
+	}
+	if result := strings.TrimSpace(string(output)); result != "ok" {
+		return fmt.Errorf("hyprctl dispatch %s failed: %s", dispatcher, result)
+	}
+	return nil
+}
+
+// Subscribe streams Hyprland window events until ctx is done
+func (h *Hyprland) Subscribe(ctx context.Context, handle func(Event)) error {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	socket := filepath.Join(runtimeDir, "hypr", os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"), ".socket2.sock")
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to hyprland events: %w", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if event, ok := parseHyprlandEvent(scanner.Text()); ok {
+			handle(event)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("hyprland event stream ended: %w", scanner.Err())
+}
+
+// parseHyprlandEvent converts a line of the event socket, e.g.
+// "activewindow>>kitty,nvim ~ api", into a window event
+func parseHyprlandEvent(line string) (Event, bool) {
+	name, data, found := strings.Cut(line, ">>")
+	if !found {
+		return Event{}, false
+	}
+
+	switch name {
+	case "openwindow": // ADDRESS,WORKSPACE,CLASS,TITLE
+		parts := strings.SplitN(data, ",", 4)
+		if len(parts) < 4 {
+			return Event{}, false
+		}
+		return Event{Change: "new", WindowID: parseHyprlandAddress(parts[0]), Title: parts[3]}, true
+	case "closewindow": // ADDRESS
+		return Event{Change: "close", WindowID: parseHyprlandAddress(data)}, true
+	case "activewindow": // CLASS,TITLE
+		_, title, _ := strings.Cut(data, ",")
+		return Event{Change: "focus", Title: title}, true
+	case "windowtitlev2": // ADDRESS,TITLE
+		address, title, _ := strings.Cut(data, ",")
+		return Event{Change: "title", WindowID: parseHyprlandAddress(address), Title: title}, true
+	default:
+		return Event{}, false
+	}
+}
+
+// parseHyprlandAddress converts a window address to a window ID, 0 when
+// it is malformed
+func parseHyprlandAddress(address string) int64 {
+	address = strings.TrimPrefix(address, "0x")
+	id, err := strconv.ParseUint(address, 16, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(id)
+}
@@ -0,0 +1,123 @@
+package window
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// KDE finds and activates windows on KWin through kdotool, which runs
+// KWin scripts over D-Bus. KWin identifies windows by UUID, so they are
+// numbered in the order they were found.
+type KDE struct {
+	mu  sync.Mutex // Guards ids, a lookup given up on may still number windows
+	ids []string   // UUIDs of found windows, window IDs index it from 1
+}
+
+// Name identifies the backend
+func (k *KDE) Name() string {
+	return "kde"
+}
+
+// Available reports whether a Plasma session and kdotool are present
+func (k *KDE) Available() bool {
+	if !strings.Contains(os.Getenv("XDG_CURRENT_DESKTOP"), "KDE") {
+		return false
+	}
+	_, err := exec.LookPath("kdotool")
+	return err == nil
+}
+
+// Capabilities returns what KWin scripts support
+func (k *KDE) Capabilities() Capability {
+	return CanFind | CanFocus
+}
+
+// kdotool runs a kdotool command, returning its output lines
+func (k *KDE) kdotool(args ...string) ([]string, error) {
+	output, err := exec.Command("kdotool", args...).Output()
+	if err != nil {
+		// search exits with 1 when nothing matches
+		if exitErr, ok := err.(*exec.ExitError); ok && args[0] == "search" && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("kdotool %s failed: %w", args[0], err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// id numbers a KWin window UUID
+func (k *KDE) id(uuid string) int64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for i, known := range k.ids {
+		if known == uuid {
+			return int64(i + 1)
+		}
+	}
+	k.ids = append(k.ids, uuid)
+	return int64(len(k.ids))
+}
+
+// uuid returns the KWin window UUID of a window ID
+func (k *KDE) uuid(windowID int64) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if windowID < 1 || windowID > int64(len(k.ids)) {
+		return "", fmt.Errorf("window %d is not open", windowID)
+	}
+	return k.ids[windowID-1], nil
+}
+
+// FindWindow finds a window by title
+func (k *KDE) FindWindow(title string) (int64, error) {
+	uuids, err := k.kdotool("search", "--name", "^"+regexp.QuoteMeta(title)+"$")
+	if err != nil || len(uuids) == 0 {
+		return 0, err
+	}
+	return k.id(uuids[0]), nil
+}
+
+// FindAppWindow finds a window of an application by the project name in
+// its title
+func (k *KDE) FindAppWindow(app, project string) (int64, error) {
+	uuids, err := k.kdotool("search", "--class", "^"+regexp.QuoteMeta(app)+"$")
+	if err != nil {
+		return 0, err
+	}
+	for _, uuid := range uuids {
+		output, err := exec.Command("kdotool", "getwindowname", uuid).Output()
+		if err != nil {
+			continue // Closed since
+		}
+		if TitleNamesProject(strings.TrimSpace(string(output)), project) {
+			return k.id(uuid), nil
+		}
+	}
+	return 0, nil
+}
+
+// FocusWindow activates a window, switching to its desktop
+func (k *KDE) FocusWindow(windowID int64) error {
+	return k.window("windowactivate", windowID)
+}
+
+// CloseWindow asks a window to close
+func (k *KDE) CloseWindow(windowID int64) error {
+	return k.window("windowclose", windowID)
+}
+
+// window runs a kdotool command against a window by ID
+func (k *KDE) window(command string, windowID int64) error {
+	uuid, err := k.uuid(windowID)
+	if err != nil {
+		return err
+	}
+	_, err = k.kdotool(command, uuid)
+	return err
+}
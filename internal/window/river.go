@@ -0,0 +1,10 @@
+package window
+
+import "os/exec"
+
+// riverRunning reports whether River is running. River has no IPC for
+// windows, they are found through the foreign toplevel protocol, but
+// riverctl only talks to River.
+func riverRunning() bool {
+	return exec.Command("riverctl", "list-inputs").Run() == nil
+}
@@ -0,0 +1,318 @@
+package window
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Sway talks to sway through swaymsg, or to i3, which speaks the same IPC
+// protocol, through i3-msg
+type Sway struct {
+	name   string
+	msg    string // IPC client command
+	socket string // Environment variable pointing at the IPC socket
+}
+
+// SwayNode represents a node in the Sway tree
+type SwayNode struct {
+	ID            int64                 `json:"id"`
+	Name          string                `json:"name"`
+	AppID         *string               `json:"app_id"`
+	PID           int                   `json:"pid"`
+	Window        *SwayWindowProperties `json:"window_properties"` // Set for X11 windows
+	Nodes         []SwayNode            `json:"nodes"`
+	FloatingNodes []SwayNode            `json:"floating_nodes"`
+}
+
+// isWindow reports whether the node is a window rather than a container:
+// Wayland windows have an app_id, X11 ones window properties
+func (n SwayNode) isWindow() bool {
+	return n.AppID != nil || n.Window != nil
+}
+
+// SwayWindowProperties holds the X11 properties of a window
+type SwayWindowProperties struct {
+	Class string `json:"class"`
+}
+
+// SwayTree represents the root of the Sway tree
+type SwayTree struct {
+	Nodes []SwayNode `json:"nodes"`
+}
+
+// Name identifies the backend
+func (s *Sway) Name() string {
+	return s.name
+}
+
+// Available reports whether a session of the window manager and its IPC
+// client are present. i3 does not always export its socket, so X11
+// sessions without it ask i3 directly.
+func (s *Sway) Available() bool {
+	if _, err := exec.LookPath(s.msg); err != nil {
+		return false
+	}
+	if os.Getenv(s.socket) != "" {
+		return true
+	}
+	if s.name == "i3" && os.Getenv("DISPLAY") != "" {
+		return exec.Command(s.msg, "-t", "get_version").Run() == nil
+	}
+	return false
+}
+
+// Capabilities returns everything the IPC protocol supports
+func (s *Sway) Capabilities() Capability {
+	return CanFind | CanFocus | CanMark | CanSubscribe
+}
+
+// getTree reads the layout tree
+func (s *Sway) getTree() (SwayTree, error) {
+	cmd := exec.Command(s.msg, "-t", "get_tree")
+	output, err := cmd.Output()
+	if err != nil {
+		return SwayTree{}, fmt.Errorf("failed to get %s tree: %w", s.name, err)
+	}
+
+	var tree SwayTree
+	if err := json.Unmarshal(output, &tree); err != nil {
+		return SwayTree{}, fmt.Errorf("failed to parse %s tree: %w", s.name, err)
+	}
+	return tree, nil
+}
+
+// FindWindow finds a window by title
+func (s *Sway) FindWindow(title string) (int64, error) {
+	tree, err := s.getTree()
+	if err != nil {
+		return 0, err
+	}
+
+	// Search for window with matching title
+	for _, node := range tree.Nodes {
+		if windowID := findNodeByTitle(node, title); windowID != 0 {
+			return windowID, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// FindAppWindow finds a window of an application by the project name in
+// its title
+func (s *Sway) FindAppWindow(app, project string) (int64, error) {
+	tree, err := s.getTree()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, node := range tree.Nodes {
+		if windowID := findAppNode(node, app, project); windowID != 0 {
+			return windowID, nil
+		}
+	}
+	return 0, nil
+}
+
+// FindWindowByPID finds the window owned by a process
+func (s *Sway) FindWindowByPID(pid int) (int64, error) {
+	tree, err := s.getTree()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, node := range tree.Nodes {
+		if windowID := findNodeByPID(node, pid); windowID != 0 {
+			return windowID, nil
+		}
+	}
+	return 0, nil
+}
+
+// WindowTitles returns the titles of all windows in the tree
+func (s *Sway) WindowTitles() ([]string, error) {
+	tree, err := s.getTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	for _, node := range tree.Nodes {
+		titles = collectTitles(node, titles)
+	}
+	return titles, nil
+}
+
+// FocusWindow focuses a window by ID
+func (s *Sway) FocusWindow(windowID int64) error {
+	return s.command(windowID, "focus")
+}
+
+// MoveToWorkspace moves a window to a workspace by name, or to the current
+// one
+func (s *Sway) MoveToWorkspace(windowID int64, workspace string) error {
+	if workspace == "" {
+		return s.command(windowID, "move container to workspace current")
+	}
+	return s.command(windowID, "move container to workspace "+swayQuote(workspace))
+}
+
+// MarkWindow sets a mark on a window
+func (s *Sway) MarkWindow(windowID int64, mark string) error {
+	return s.command(windowID, "mark --add "+swayQuote(mark))
+}
+
+// RunCommand runs a sway or i3 command on a window
+func (s *Sway) RunCommand(windowID int64, command string) error {
+	return s.command(windowID, command)
+}
+
+// CloseWindow asks a window to close
+func (s *Sway) CloseWindow(windowID int64) error {
+	return s.command(windowID, "kill")
+}
+
+// command runs an IPC command against a single container
+func (s *Sway) command(windowID int64, command string) error {
+	cmd := exec.Command(s.msg, swayCommand(windowID, command))
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", s.msg, command, err)
+	}
+
+	// Check if the command succeeded
+	if strings.Contains(string(output), "success") {
+		return nil
+	}
+
+	return fmt.Errorf("%s %s command failed: %s", s.msg, command, string(output))
+}
+
+// swayCommand scopes a command to a single container by ID, never by
+// title, so window titles cannot break out of the criteria
+func swayCommand(windowID int64, command string) string {
+	return fmt.Sprintf(`[con_id="%d"] %s`, windowID, command)
+}
+
+// swayQuote quotes an argument of a sway or i3 command as a single
+// string, escaping the quotes and backslashes in it
+func swayQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// swayWindowEvent is the payload of a sway window event
+type swayWindowEvent struct {
+	Change    string   `json:"change"`
+	Container SwayNode `json:"container"`
+}
+
+// Subscribe streams window events until ctx is done
+func (s *Sway) Subscribe(ctx context.Context, handle func(Event)) error {
+	cmd := exec.CommandContext(ctx, s.msg, "-t", "subscribe", "-m", `["window"]`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s events: %w", s.name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to subscribe to %s events: %w", s.name, err)
+	}
+
+	decoder := json.NewDecoder(stdout)
+	for {
+		var event swayWindowEvent
+		if err := decoder.Decode(&event); err != nil {
+			cmd.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("%s event stream ended: %w", s.name, err)
+		}
+		handle(Event{
+			Change:   event.Change,
+			WindowID: event.Container.ID,
+			Title:    event.Container.Name,
+		})
+	}
+}
+
+// collectTitles appends the titles of all windows below node
+func collectTitles(node SwayNode, titles []string) []string {
+	if node.isWindow() {
+		titles = append(titles, node.Name)
+	}
+	for _, n := range node.Nodes {
+		titles = collectTitles(n, titles)
+	}
+	for _, n := range node.FloatingNodes {
+		titles = collectTitles(n, titles)
+	}
+	return titles
+}
+
+// findAppNode recursively searches for a window of app naming the project
+func findAppNode(node SwayNode, app, project string) int64 {
+	isApp := node.AppID != nil && strings.EqualFold(*node.AppID, app) ||
+		node.Window != nil && strings.EqualFold(node.Window.Class, app)
+	if isApp && TitleNamesProject(node.Name, project) {
+		return node.ID
+	}
+
+	for _, n := range node.Nodes {
+		if windowID := findAppNode(n, app, project); windowID != 0 {
+			return windowID
+		}
+	}
+	for _, n := range node.FloatingNodes {
+		if windowID := findAppNode(n, app, project); windowID != 0 {
+			return windowID
+		}
+	}
+	return 0
+}
+
+// findNodeByPID recursively searches for a window owned by pid
+func findNodeByPID(node SwayNode, pid int) int64 {
+	if node.PID == pid && node.isWindow() {
+		return node.ID
+	}
+
+	for _, n := range node.Nodes {
+		if windowID := findNodeByPID(n, pid); windowID != 0 {
+			return windowID
+		}
+	}
+	for _, n := range node.FloatingNodes {
+		if windowID := findNodeByPID(n, pid); windowID != 0 {
+			return windowID
+		}
+	}
+	return 0
+}
+
+// findNodeByTitle recursively searches for a node with the given title
+func findNodeByTitle(node SwayNode, title string) int64 {
+	// Check if this node matches
+	if node.isWindow() && node.Name == title {
+		return node.ID
+	}
+
+	// Search in regular nodes
+	for _, n := range node.Nodes {
+		if windowID := findNodeByTitle(n, title); windowID != 0 {
+			return windowID
+		}
+	}
+
+	// Search in floating nodes
+	for _, n := range node.FloatingNodes {
+		if windowID := findNodeByTitle(n, title); windowID != 0 {
+			return windowID
+		}
+	}
+
+	return 0
+}
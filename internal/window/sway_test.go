@@ -0,0 +1,25 @@
+package window
+
+import "testing"
+
+func TestSwayCommandCriteria(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"plain", "mark --add " + swayQuote("api"), `[con_id="42"] mark --add "api"`},
+		{"closing bracket", "mark --add " + swayQuote(`a"]b`), `[con_id="42"] mark --add "a\"]b"`},
+		{"quotes", "mark --add " + swayQuote(`say "hi"`), `[con_id="42"] mark --add "say \"hi\""`},
+		{"backslash", "mark --add " + swayQuote(`a\"; kill`), `[con_id="42"] mark --add "a\\\"; kill"`},
+		{"separators", "move container to workspace " + swayQuote("x; exec rm, kill"), `[con_id="42"] move container to workspace "x; exec rm, kill"`},
+		{"command substitution", "mark --add " + swayQuote("$(id) `id`"), "[con_id=\"42\"] mark --add \"$(id) `id`\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := swayCommand(42, tt.command); got != tt.want {
+				t.Errorf("swayCommand = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package window
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Wlroots finds and focuses windows on any compositor implementing the
+// wlr foreign toplevel protocol, through wlrctl. Toplevels have no IDs, so
+// windows are numbered in the order they were last listed.
+type Wlroots struct {
+	name    string
+	running func() bool // Reports whether the compositor is running
+
+	mu      sync.Mutex // Guards windows, a lookup given up on may still list
+	windows []Toplevel // Last listing, window IDs index it from 1
+}
+
+// Toplevel is a window as listed by `wlrctl toplevel list`
+type Toplevel struct {
+	AppID string
+	Title string
+}
+
+// Name identifies the backend
+func (wl *Wlroots) Name() string {
+	return wl.name
+}
+
+// Available reports whether a Wayland session of the compositor and
+// wlrctl are present
+func (wl *Wlroots) Available() bool {
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	if _, err := exec.LookPath("wlrctl"); err != nil {
+		return false
+	}
+	return wl.running()
+}
+
+// toplevelsListed reports whether the compositor lists its toplevels,
+// which fails on those without the protocol
+func toplevelsListed() bool {
+	return exec.Command("wlrctl", "toplevel", "list").Run() == nil
+}
+
+// Capabilities returns what the foreign toplevel protocol supports
+func (wl *Wlroots) Capabilities() Capability {
+	return CanFind | CanFocus
+}
+
+// toplevels lists the open windows
+func (wl *Wlroots) toplevels() ([]Toplevel, error) {
+	output, err := exec.Command("wlrctl", "toplevel", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list toplevels: %w", err)
+	}
+
+	var windows []Toplevel
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		appID, title, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		windows = append(windows, Toplevel{AppID: appID, Title: title})
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.windows = windows
+	return windows, nil
+}
+
+// listed returns the window of an ID in the last listing, listing the
+// windows first when they never were
+func (wl *Wlroots) listed(windowID int64) (Toplevel, error) {
+	wl.mu.Lock()
+	windows := wl.windows
+	wl.mu.Unlock()
+
+	if windows == nil {
+		var err error
+		if windows, err = wl.toplevels(); err != nil {
+			return Toplevel{}, err
+		}
+	}
+	if windowID < 1 || windowID > int64(len(windows)) {
+		return Toplevel{}, fmt.Errorf("window %d is not open", windowID)
+	}
+	return windows[windowID-1], nil
+}
+
+// find returns the ID of the first window match accepts
+func (wl *Wlroots) find(match func(Toplevel) bool) (int64, error) {
+	windows, err := wl.toplevels()
+	if err != nil {
+		return 0, err
+	}
+	for i, w := range windows {
+		if match(w) {
+			return int64(i + 1), nil
+		}
+	}
+	return 0, nil
+}
+
+// FindWindow finds a window by title
+func (wl *Wlroots) FindWindow(title string) (int64, error) {
+	return wl.find(func(w Toplevel) bool { return w.Title == title })
+}
+
+// FindAppWindow finds a window of an application by the project name in
+// its title
+func (wl *Wlroots) FindAppWindow(app, project string) (int64, error) {
+	return wl.find(func(w Toplevel) bool {
+		return strings.EqualFold(w.AppID, app) && TitleNamesProject(w.Title, project)
+	})
+}
+
+// WindowTitles returns the titles of all windows
+func (wl *Wlroots) WindowTitles() ([]string, error) {
+	windows, err := wl.toplevels()
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, 0, len(windows))
+	for _, w := range windows {
+		titles = append(titles, w.Title)
+	}
+	return titles, nil
+}
+
+// FocusWindow activates a window by ID
+func (wl *Wlroots) FocusWindow(windowID int64) error {
+	return wl.toplevel("focus", windowID)
+}
+
+// CloseWindow asks a window to close
+func (wl *Wlroots) CloseWindow(windowID int64) error {
+	return wl.toplevel("close", windowID)
+}
+
+// toplevel runs a wlrctl action on the window with the app ID and title of
+// a listed one
+func (wl *Wlroots) toplevel(action string, windowID int64) error {
+	w, err := wl.listed(windowID)
+	if err != nil {
+		return err
+	}
+
+	output, err := exec.Command("wlrctl", "toplevel", action, "app_id:"+w.AppID, "title:"+w.Title).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wlrctl toplevel %s failed: %w: %s", action, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
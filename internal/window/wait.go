@@ -0,0 +1,52 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	initialBackoff = 100 * time.Millisecond
+	backoffFactor  = 2
+)
+
+// ErrWindowTimeout is returned when a window does not appear in time
+var ErrWindowTimeout = errors.New("timed out waiting for the window")
+
+// WaitForWindow waits until find returns a window ID. Backends that stream
+// window events are checked again as soon as a window opens or changes its
+// title; polling with an increasing backoff covers events missed while
+// subscribing and backends without events.
+func WaitForWindow(ctx context.Context, b Backend, find func() int64) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	if subscriber, ok := b.(Subscriber); ok && b.Capabilities().Has(CanSubscribe) {
+		go subscriber.Subscribe(ctx, func(event Event) {
+			if event.Change != "new" && event.Change != "title" {
+				return
+			}
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	backoff := initialBackoff
+	for {
+		if windowID := find(); windowID != 0 {
+			return windowID, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ErrWindowTimeout
+		case <-changed:
+		case <-time.After(backoff):
+			backoff *= backoffFactor
+		}
+	}
+}
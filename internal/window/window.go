@@ -0,0 +1,245 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Capability is a set of operations a backend supports
+type Capability uint
+
+const (
+	// CanFind means windows can be looked up by title
+	CanFind Capability = 1 << iota
+	// CanFocus means a found window can be focused
+	CanFocus
+	// CanMark means windows can be tagged with a mark
+	CanMark
+	// CanSubscribe means the backend can stream window events
+	CanSubscribe
+)
+
+// Has reports whether every capability in c is present
+func (caps Capability) Has(c Capability) bool {
+	return caps&c == c
+}
+
+// Backend finds and focuses windows on a specific compositor
+type Backend interface {
+	// Name identifies the backend in config and diagnostics
+	Name() string
+	// Available reports whether the backend can talk to the running session
+	Available() bool
+	// Capabilities returns the operations the backend supports
+	Capabilities() Capability
+	// FindWindow returns the ID of the window with the given title, or 0
+	FindWindow(title string) (int64, error)
+	// FocusWindow focuses a window by ID
+	FocusWindow(windowID int64) error
+}
+
+// Marker is implemented by backends with the CanMark capability
+type Marker interface {
+	MarkWindow(windowID int64, mark string) error
+}
+
+// Closer is implemented by backends that can close windows
+type Closer interface {
+	CloseWindow(windowID int64) error
+}
+
+// Mover is implemented by backends that can move windows between
+// workspaces
+type Mover interface {
+	// MoveToWorkspace moves a window to a workspace named the way the
+	// compositor names them, or to the current one when workspace is empty
+	MoveToWorkspace(windowID int64, workspace string) error
+}
+
+// Bring moves a window to the current workspace when the backend can, and
+// focuses it
+func Bring(b Backend, windowID int64) error {
+	if mover, ok := b.(Mover); ok {
+		if err := mover.MoveToWorkspace(windowID, ""); err != nil {
+			return err
+		}
+	}
+	return b.FocusWindow(windowID)
+}
+
+// Commander is implemented by backends that run window manager commands
+type Commander interface {
+	// RunCommand runs a command of the window manager on a window, e.g.
+	// "fullscreen enable" on sway
+	RunCommand(windowID int64, command string) error
+}
+
+// Lister is implemented by backends that can list every window at once,
+// which is cheaper than finding windows one by one
+type Lister interface {
+	// WindowTitles returns the titles of all open windows
+	WindowTitles() ([]string, error)
+}
+
+// AppFinder is implemented by backends that can find windows by
+// application, for GUI editors that pick their own window titles
+type AppFinder interface {
+	// FindAppWindow returns the ID of a window of app (Wayland app_id or X11
+	// class) whose title names the project, or 0
+	FindAppWindow(app, project string) (int64, error)
+}
+
+// PIDFinder is implemented by backends that can find the window of a process
+type PIDFinder interface {
+	// FindWindowByPID returns the ID of the window owned by pid, or 0
+	FindWindowByPID(pid int) (int64, error)
+}
+
+// titleSeparator splits window titles such as "main.go — api — Visual
+// Studio Code" or "api [~/Dev/api] – main.go" into their parts
+var titleSeparator = regexp.MustCompile(`\s+[-–—]\s+`)
+
+// TitleNamesProject reports whether one part of a GUI window title is the
+// project name, optionally followed by its location in brackets
+func TitleNamesProject(title, project string) bool {
+	for _, part := range titleSeparator.Split(title, -1) {
+		part, _, _ = strings.Cut(part, " [")
+		if strings.TrimSpace(part) == project {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a window event delivered to subscribers
+type Event struct {
+	Change   string // e.g. "new", "focus", "close", "title"
+	WindowID int64
+	Title    string
+}
+
+// Subscriber is implemented by backends with the CanSubscribe capability
+type Subscriber interface {
+	// Subscribe calls handle for every window event until ctx is done
+	Subscribe(ctx context.Context, handle func(Event)) error
+}
+
+// registration is a backend factory in detection order
+type registration struct {
+	name    string
+	factory func() Backend
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// The built-in backends in detection order: those of a specific window
+// manager or compositor first, then the generic Wayland and X11 ones, so
+// they only cover the others
+func init() {
+	Register("gnome", func() Backend { return &Gnome{} })
+	Register("hyprland", func() Backend { return &Hyprland{} })
+	Register("kde", func() Backend { return &KDE{} })
+	Register("river", func() Backend { return &Wlroots{name: "river", running: riverRunning} })
+	Register("sway", func() Backend { return &Sway{name: "sway", msg: "swaymsg", socket: "SWAYSOCK"} })
+	Register("i3", func() Backend { return &Sway{name: "i3", msg: "i3-msg", socket: "I3SOCK"} })
+	Register("wlroots", func() Backend { return &Wlroots{name: "wlroots", running: toplevelsListed} })
+	Register("x11", func() Backend { return &X11{} })
+}
+
+// Register adds a backend factory. Backends are probed in registration order.
+func Register(name string, factory func() Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry = append(registry, registration{name: name, factory: factory})
+}
+
+// Get returns the backend registered under name
+func Get(name string) (Backend, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if r.name == name {
+			return r.factory(), true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the first available backend, falling back to one that
+// can only launch and never finds or focuses windows. Backends are probed
+// once per process.
+func Detect() Backend {
+	detectOnce.Do(func() {
+		registryMu.RLock()
+		defer registryMu.RUnlock()
+
+		detected = None{}
+		for _, r := range registry {
+			if b := r.factory(); b.Available() {
+				detected = b
+				return
+			}
+		}
+	})
+	return detected
+}
+
+var (
+	detectOnce sync.Once
+	detected   Backend
+)
+
+// Select returns the backend named in config: detected for "" or "auto",
+// launch-only for "none", or else the registered backend of that name
+func Select(name string) (Backend, error) {
+	switch name {
+	case "", "auto":
+		return Detect(), nil
+	case None{}.Name():
+		return None{}, nil
+	}
+	if b, ok := Get(name); ok {
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("unknown window backend %q (available: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names Select accepts, registered backends in
+// detection order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := []string{"auto", None{}.Name()}
+	for _, r := range registry {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// None is the backend used when no supported window manager is running
+type None struct{}
+
+// Name identifies the backend
+func (None) Name() string { return "none" }
+
+// Available is always true
+func (None) Available() bool { return true }
+
+// Capabilities is empty: windows are never found or focused
+func (None) Capabilities() Capability { return 0 }
+
+// FindWindow never finds a window
+func (None) FindWindow(title string) (int64, error) { return 0, nil }
+
+// FocusWindow is a no-op
+func (None) FocusWindow(windowID int64) error { return nil }
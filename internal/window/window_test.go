@@ -0,0 +1,13 @@
+package window
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectionOrder(t *testing.T) {
+	want := []string{"auto", "none", "gnome", "hyprland", "kde", "river", "sway", "i3", "wlroots", "x11"}
+	if got := Names(); !slices.Equal(got, want) {
+		t.Errorf("Names() = %q, want %q", got, want)
+	}
+}
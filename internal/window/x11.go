@@ -0,0 +1,145 @@
+package window
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// X11 finds and activates windows on any EWMH compliant X11 window
+// manager through wmctrl
+type X11 struct{}
+
+// X11Window is a window as listed by `wmctrl -lpx`
+type X11Window struct {
+	ID    int64
+	PID   int
+	Class string // WM_CLASS as instance.class
+	Title string
+}
+
+// wmctrlLine matches the window ID, desktop, pid, class, client machine
+// and title of a `wmctrl -lpx` line
+var wmctrlLine = regexp.MustCompile(`^(0x[0-9a-fA-F]+)\s+(-?\d+)\s+(\d+)\s+(\S+)\s+(\S+)\s?(.*)$`)
+
+// Name identifies the backend
+func (x *X11) Name() string {
+	return "x11"
+}
+
+// Available reports whether an X11 display and wmctrl are present
+func (x *X11) Available() bool {
+	if os.Getenv("DISPLAY") == "" {
+		return false
+	}
+	_, err := exec.LookPath("wmctrl")
+	return err == nil
+}
+
+// Capabilities returns what EWMH allows
+func (x *X11) Capabilities() Capability {
+	return CanFind | CanFocus
+}
+
+// windows lists the managed windows
+func (x *X11) windows() ([]X11Window, error) {
+	output, err := exec.Command("wmctrl", "-lpx").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list x11 windows: %w", err)
+	}
+
+	var windows []X11Window
+	for _, line := range strings.Split(string(output), "\n") {
+		match := wmctrlLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(match[1], "0x"), 16, 64)
+		if err != nil {
+			continue
+		}
+		pid, _ := strconv.Atoi(match[3])
+		windows = append(windows, X11Window{ID: id, PID: pid, Class: match[4], Title: match[6]})
+	}
+	return windows, nil
+}
+
+// find returns the ID of the first window match accepts
+func (x *X11) find(match func(X11Window) bool) (int64, error) {
+	windows, err := x.windows()
+	if err != nil {
+		return 0, err
+	}
+	for _, w := range windows {
+		if match(w) {
+			return w.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// FindWindow finds a window by title
+func (x *X11) FindWindow(title string) (int64, error) {
+	return x.find(func(w X11Window) bool { return w.Title == title })
+}
+
+// FindAppWindow finds a window of an application, matching either part of
+// its class, by the project name in its title
+func (x *X11) FindAppWindow(app, project string) (int64, error) {
+	return x.find(func(w X11Window) bool {
+		instance, class, _ := strings.Cut(w.Class, ".")
+		isApp := strings.EqualFold(instance, app) || strings.EqualFold(class, app)
+		return isApp && TitleNamesProject(w.Title, project)
+	})
+}
+
+// FindWindowByPID finds the window owned by a process
+func (x *X11) FindWindowByPID(pid int) (int64, error) {
+	return x.find(func(w X11Window) bool { return w.PID == pid })
+}
+
+// WindowTitles returns the titles of all windows
+func (x *X11) WindowTitles() ([]string, error) {
+	windows, err := x.windows()
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, 0, len(windows))
+	for _, w := range windows {
+		titles = append(titles, w.Title)
+	}
+	return titles, nil
+}
+
+// FocusWindow activates a window, switching to its desktop
+func (x *X11) FocusWindow(windowID int64) error {
+	return x.wmctrl("-a", windowID)
+}
+
+// MoveToWorkspace moves a window to a desktop by number, or to the current
+// one, which also activates it
+func (x *X11) MoveToWorkspace(windowID int64, workspace string) error {
+	if workspace == "" {
+		return x.wmctrl("-R", windowID)
+	}
+	return x.wmctrl("-r", windowID, "-t", workspace)
+}
+
+// CloseWindow asks a window to close
+func (x *X11) CloseWindow(windowID int64) error {
+	return x.wmctrl("-c", windowID)
+}
+
+// wmctrl runs a wmctrl action against a window by ID
+func (x *X11) wmctrl(action string, windowID int64, args ...string) error {
+	argv := append([]string{"-i", action, fmt.Sprintf("0x%08x", windowID)}, args...)
+	output, err := exec.Command("wmctrl", argv...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wmctrl %s failed: %w: %s", action, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
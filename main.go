@@ -28,12 +28,21 @@ import (
 	"github.com/marianozunino/code/v2/cmd"
 )
 
-var version = "v2.0.0"
+// Build details, set with -ldflags "-X main.version=... -X main.commit=...
+// -X main.date=..."
+var (
+	version = "v2.0.0"
+	commit  string
+	date    string
+)
 
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "--version" {
 		fmt.Println(version)
 		return
 	}
-	cmd.Execute()
+	cmd.SetBuildInfo(version, commit, date)
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }